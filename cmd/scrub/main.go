@@ -0,0 +1,176 @@
+// Command scrub sanitizes JSON, newline-delimited JSON and YAML documents, such as log exports and database
+// dumps, so they can be shared.
+//
+// It reads the named files, or standard input if there are none, and writes the scrubbed documents to
+// standard output. Fields are selected by name or dotted path with flags, or with rules in a policy file, and
+// each selected value is zeroed, omitted, masked, hashed or faked. JSON documents are streamed, so they can
+// be of any size, and each value is written compactly on its own line. YAML documents keep their comments and
+// key order, and aliases are scrubbed at their own paths, as well as at their anchors'.
+//
+// Usage:
+//
+//	scrub -names password,token -action mask export.ndjson > export.scrubbed.ndjson
+//	scrub -policy policy.yaml -format yaml < values.yaml
+//
+// A policy file, in JSON or YAML, holds a list of rules. Each rule selects values by name, glob or path,
+// and may set an action, which defaults to zero:
+//
+//	rules:
+//	  - name: password
+//	    action: omit
+//	  - glob: "*_token"
+//	    action: hash
+//	  - path: customer.address.**
+//
+// Flags:
+//
+//	-names   comma-separated field names to scrub
+//	-paths   comma-separated dotted paths to scrub, which may contain * and ** wildcards
+//	-action  action for -names and -paths: zero, omit, mask, hash, fake, redact or url (default zero)
+//	-policy  policy file with rules, applied before -names and -paths
+//	-format  json, ndjson or yaml; defaults to the input file's extension, or json
+//	-o       output file; defaults to standard output
+package main
+
+import (
+	"bufio"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/acj/scrub"
+	"github.com/acj/scrub/scrubjson"
+)
+
+func main() {
+	names := flag.String("names", "", "comma-separated field names to scrub")
+	paths := flag.String("paths", "", "comma-separated dotted paths to scrub, which may contain * and ** wildcards")
+	action := flag.String("action", string(scrub.Zero), "action for -names and -paths: zero, omit, mask, hash, fake, redact or url")
+	policy := flag.String("policy", "", "policy file with rules, applied before -names and -paths")
+	format := flag.String("format", "", "json, ndjson or yaml; defaults to the input file's extension, or json")
+	output := flag.String("o", "", "output file; defaults to standard output")
+	flag.Usage = func() {
+		fmt.Fprintf(os.Stderr, "usage: scrub [flags] [file ...]\n")
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
+	rules, err := buildRules(*policy, split(*names), split(*paths), *action)
+	if err == nil && len(rules) == 0 {
+		err = errors.New("nothing to scrub: use -names, -paths or -policy")
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "scrub: %v\n", err)
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	if err := run(rules, *format, flag.Args(), *output); err != nil {
+		fmt.Fprintf(os.Stderr, "scrub: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+func split(list string) []string {
+	if list == "" {
+		return nil
+	}
+	return strings.Split(list, ",")
+}
+
+// buildRules returns the rules from the policy file, if any, followed by rules for the given names and
+// paths.
+func buildRules(policy string, names, paths []string, action string) ([]scrub.Rule, error) {
+	a, err := scrub.ParseAction(action)
+	if err != nil {
+		return nil, err
+	}
+
+	var rules []scrub.Rule
+	if policy != "" {
+		if rules, err = loadPolicy(policy); err != nil {
+			return nil, err
+		}
+	}
+	for _, name := range names {
+		rules = append(rules, scrub.Rule{Name: name, Action: a})
+	}
+	for _, path := range paths {
+		rules = append(rules, scrub.Rule{Path: path, Action: a})
+	}
+	return rules, nil
+}
+
+func run(rules []scrub.Rule, format string, files []string, output string) error {
+	out := os.Stdout
+	if output != "" {
+		f, err := os.Create(output)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		out = f
+	}
+
+	w := bufio.NewWriter(out)
+	if len(files) == 0 {
+		if err := scrubStream(w, os.Stdin, format, rules); err != nil {
+			return err
+		}
+	}
+	for _, file := range files {
+		if err := scrubFile(w, file, format, rules); err != nil {
+			return err
+		}
+	}
+	if err := w.Flush(); err != nil {
+		return err
+	}
+	if output != "" {
+		return out.Close()
+	}
+	return nil
+}
+
+func scrubFile(w io.Writer, file, format string, rules []scrub.Rule) error {
+	f, err := os.Open(file)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	if format == "" {
+		format = formatFromExt(file)
+	}
+	if err := scrubStream(w, f, format, rules); err != nil {
+		return fmt.Errorf("%s: %w", file, err)
+	}
+	return nil
+}
+
+// scrubStream scrubs the documents read from r, in the given format, and writes them to w.
+func scrubStream(w io.Writer, r io.Reader, format string, rules []scrub.Rule) error {
+	switch format {
+	case "", "json", "ndjson":
+		return scrubjson.Scrub(w, r, scrubjson.WithRules(rules...))
+	case "yaml":
+		return scrubYAML(w, r, rules)
+	default:
+		return fmt.Errorf("unknown format %q", format)
+	}
+}
+
+func formatFromExt(file string) string {
+	switch strings.ToLower(filepath.Ext(file)) {
+	case ".ndjson", ".jsonl":
+		return "ndjson"
+	case ".yaml", ".yml":
+		return "yaml"
+	default:
+		return "json"
+	}
+}
@@ -0,0 +1,134 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/acj/scrub"
+)
+
+func writeFile(t *testing.T, dir, name, content string) string {
+	t.Helper()
+	path := filepath.Join(dir, name)
+	require.NoError(t, os.WriteFile(path, []byte(content), 0o644))
+	return path
+}
+
+func TestRun(t *testing.T) {
+	rules := []scrub.Rule{{Name: "password", Action: scrub.Omit}, {Path: "user.email", Action: scrub.Mask}}
+
+	t.Run("with files in each format, scrubs them into the output", func(t *testing.T) {
+		dir := t.TempDir()
+		files := []string{
+			writeFile(t, dir, "a.json", "{\n  \"user\": {\"email\": \"testy@example.com\", \"password\": \"hunter2\"}\n}\n"),
+			writeFile(t, dir, "b.ndjson", "{\"password\": \"hunter2\", \"n\": 1}\n{\"password\": \"hunter3\", \"n\": 2}\n"),
+			writeFile(t, dir, "c.yml", "user:\n  email: testy@example.com\n  password: hunter2\n"),
+		}
+		output := filepath.Join(dir, "out")
+
+		require.NoError(t, run(rules, "", files, output))
+
+		got, err := os.ReadFile(output)
+		require.NoError(t, err)
+		assert.Equal(t, `{"user":{"email":"****"}}
+{"n":1}
+{"n":2}
+user:
+  email: '****'
+`, string(got))
+	})
+
+	t.Run("with a format, uses it instead of the file extension", func(t *testing.T) {
+		dir := t.TempDir()
+		file := writeFile(t, dir, "export.txt", "password: hunter2\nn: 1\n")
+		output := filepath.Join(dir, "out")
+
+		require.NoError(t, run(rules, "yaml", []string{file}, output))
+
+		got, err := os.ReadFile(output)
+		require.NoError(t, err)
+		assert.Equal(t, "n: 1\n", string(got))
+	})
+
+	t.Run("with invalid input, returns an error naming the file", func(t *testing.T) {
+		dir := t.TempDir()
+		file := writeFile(t, dir, "bad.json", `{"password": `)
+
+		err := run(rules, "", []string{file}, filepath.Join(dir, "out"))
+		assert.ErrorContains(t, err, file)
+	})
+
+	t.Run("with an unknown format, returns an error", func(t *testing.T) {
+		dir := t.TempDir()
+		file := writeFile(t, dir, "a.json", `{}`)
+
+		err := run(rules, "xml", []string{file}, filepath.Join(dir, "out"))
+		assert.ErrorContains(t, err, `unknown format "xml"`)
+	})
+}
+
+func TestBuildRules(t *testing.T) {
+	t.Run("with a policy, names and paths, returns the policy's rules first", func(t *testing.T) {
+		policy := writeFile(t, t.TempDir(), "policy.yaml", "rules:\n  - glob: \"*_token\"\n    action: hash\n")
+
+		rules, err := buildRules(policy, []string{"password"}, []string{"user.email"}, "mask")
+		require.NoError(t, err)
+
+		assert.Equal(t, []scrub.Rule{
+			{Glob: "*_token", Action: scrub.Hash},
+			{Name: "password", Action: scrub.Mask},
+			{Path: "user.email", Action: scrub.Mask},
+		}, rules)
+	})
+
+	t.Run("with an unknown action, returns an error", func(t *testing.T) {
+		_, err := buildRules("", []string{"password"}, nil, "shred")
+		assert.EqualError(t, err, `scrub: unknown action "shred"`)
+	})
+}
+
+func TestLoadPolicy(t *testing.T) {
+	t.Run("with a JSON policy, returns its rules", func(t *testing.T) {
+		policy := writeFile(t, t.TempDir(), "policy.json", `{"rules": [{"name": "password", "action": "omit"}, {"path": "user.**"}]}`)
+
+		rules, err := loadPolicy(policy)
+		require.NoError(t, err)
+		assert.Equal(t, []scrub.Rule{{Name: "password", Action: scrub.Omit}, {Path: "user.**"}}, rules)
+	})
+
+	t.Run("with an unknown field, returns an error", func(t *testing.T) {
+		dir := t.TempDir()
+		_, err := loadPolicy(writeFile(t, dir, "policy.json", `{"rules": [{"nmae": "password"}]}`))
+		assert.ErrorContains(t, err, "nmae")
+		_, err = loadPolicy(writeFile(t, dir, "policy.yaml", "rules:\n  - nmae: password\n"))
+		assert.ErrorContains(t, err, "nmae")
+	})
+
+	t.Run("with an invalid rule, returns an error naming it", func(t *testing.T) {
+		policy := writeFile(t, t.TempDir(), "policy.yaml", "rules:\n  - name: password\n  - action: mask\n")
+
+		_, err := loadPolicy(policy)
+		assert.EqualError(t, err, policy+": scrub: policy rule 2: rule needs a name, glob or path")
+	})
+
+	t.Run("with an empty YAML policy, returns no rules", func(t *testing.T) {
+		rules, err := loadPolicy(writeFile(t, t.TempDir(), "policy.yaml", ""))
+		require.NoError(t, err)
+		assert.Empty(t, rules)
+	})
+
+	t.Run("with a JSON policy in a file not named .json, parses it", func(t *testing.T) {
+		rules, err := loadPolicy(writeFile(t, t.TempDir(), "policy", `{"rules": [{"name": "password"}]}`))
+		require.NoError(t, err)
+		assert.Equal(t, []scrub.Rule{{Name: "password"}}, rules)
+	})
+
+	t.Run("with rules for types, returns an error", func(t *testing.T) {
+		_, err := loadPolicy(writeFile(t, t.TempDir(), "policy.yaml", "types:\n  User:\n    - name: Password\n"))
+		assert.ErrorContains(t, err, `unregistered type "User"`)
+	})
+}
@@ -0,0 +1,24 @@
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/acj/scrub"
+)
+
+// loadPolicy reads the rules from the policy file at path, parsed with scrub.ParsePolicy. Policies with rules
+// for types are rejected as naming unregistered types, since the documents scrubbed by the command have no
+// Go types to register.
+func loadPolicy(path string) ([]scrub.Rule, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	p, err := scrub.ParsePolicy(data)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return p.Rules, nil
+}
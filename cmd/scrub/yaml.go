@@ -0,0 +1,181 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/acj/scrub"
+)
+
+// scrubYAML scrubs the YAML documents read from r and writes them to w. Selected strings are replaced
+// according to the rule's action, other selected values are replaced with their zero values, and mappings
+// and sequences with null. Aliases are replaced with copies of their anchors' scrubbed values, which are
+// scrubbed again at the aliases' paths, and are only kept if the copies come out the same.
+func scrubYAML(w io.Writer, r io.Reader, rules []scrub.Rule) error {
+	dec := yaml.NewDecoder(r)
+	enc := yaml.NewEncoder(w)
+	enc.SetIndent(2)
+	for {
+		var doc yaml.Node
+		err := dec.Decode(&doc)
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return err
+		}
+		s := yamlScrubber{rules: rules, anchors: map[*yaml.Node]bool{}}
+		s.node(&doc)
+		if s.err != nil {
+			return s.err
+		}
+		if err := enc.Encode(&doc); err != nil {
+			return err
+		}
+	}
+	return enc.Close()
+}
+
+// maxYAMLCopies limits the nodes copied to expand aliases in a document, so that documents with aliases of
+// aliases, which double in size at each level, can't exhaust memory.
+const maxYAMLCopies = 1 << 20
+
+type yamlScrubber struct {
+	rules []scrub.Rule
+	// path holds the mapping keys leading to the current node.
+	path []string
+	// anchors holds the anchored nodes that have been scrubbed and are still in the document.
+	anchors map[*yaml.Node]bool
+	copies  int
+	err     error
+}
+
+func (s *yamlScrubber) node(n *yaml.Node) {
+	if n.Anchor != "" {
+		s.anchors[n] = true
+	}
+	switch n.Kind {
+	case yaml.AliasNode:
+		s.alias(n)
+	case yaml.DocumentNode, yaml.SequenceNode:
+		for _, c := range n.Content {
+			s.node(c)
+		}
+	case yaml.MappingNode:
+		content := n.Content[:0]
+		for i := 0; i+1 < len(n.Content); i += 2 {
+			key, value := n.Content[i], n.Content[i+1]
+			if key.ShortTag() == "!!merge" {
+				// The merged keys belong to this mapping, so they're scrubbed at its path.
+				s.node(value)
+				content = append(content, key, value)
+				continue
+			}
+			s.path = append(s.path, key.Value)
+			if a, ok := s.action(key.Value); ok {
+				if a == scrub.Omit {
+					s.path = s.path[:len(s.path)-1]
+					continue
+				}
+				replaceYAML(value, a)
+				if value.Anchor != "" {
+					s.anchors[value] = true
+				}
+			} else {
+				s.node(value)
+			}
+			content = append(content, key, value)
+			s.path = s.path[:len(s.path)-1]
+		}
+		n.Content = content
+	}
+}
+
+// alias replaces the alias n with a copy of its anchor's scrubbed value, scrubbed again at n's path, so that
+// the rules for both paths apply, and restores the alias if the copy comes out the same.
+func (s *yamlScrubber) alias(n *yaml.Node) {
+	alias := *n
+	if !s.anchors[alias.Alias] {
+		// The anchor is inside a value that was replaced or removed, so its value is too.
+		replaceYAML(n, scrub.Zero)
+		return
+	}
+	*n = *s.copy(alias.Alias)
+	n.HeadComment, n.LineComment, n.FootComment = alias.HeadComment, alias.LineComment, alias.FootComment
+	s.node(n)
+	if equalYAML(n, alias.Alias) {
+		*n = alias
+	}
+}
+
+// copy returns a deep copy of n without anchors, so that the anchors in the document stay unique.
+func (s *yamlScrubber) copy(n *yaml.Node) *yaml.Node {
+	s.copies++
+	if s.copies > maxYAMLCopies {
+		if s.err == nil {
+			s.err = fmt.Errorf("yaml: document expands to more than %d nodes through aliases", maxYAMLCopies)
+		}
+		return &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!null", Value: "null"}
+	}
+	c := *n
+	c.Anchor = ""
+	c.Content = nil
+	for _, child := range n.Content {
+		c.Content = append(c.Content, s.copy(child))
+	}
+	return &c
+}
+
+// equalYAML reports whether a and b hold the same values, ignoring styles, comments and anchors.
+func equalYAML(a, b *yaml.Node) bool {
+	if a.Kind != b.Kind || a.ShortTag() != b.ShortTag() || a.Value != b.Value || a.Alias != b.Alias ||
+		len(a.Content) != len(b.Content) {
+		return false
+	}
+	for i := range a.Content {
+		if !equalYAML(a.Content[i], b.Content[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+func (s *yamlScrubber) action(key string) (scrub.Action, bool) {
+	for _, r := range s.rules {
+		if r.Matches(key, s.path) {
+			return r.ActionOrDefault(), true
+		}
+	}
+	return "", false
+}
+
+// replaceYAML replaces the value n in place with what a replaces it with.
+func replaceYAML(n *yaml.Node, a scrub.Action) {
+	if n.Kind == yaml.ScalarNode {
+		switch n.ShortTag() {
+		case "!!str":
+			n.Value = a.Replace(n.Value)
+			n.Style = 0
+			return
+		case "!!int", "!!float":
+			n.Value = "0"
+			return
+		case "!!bool":
+			n.Value = "false"
+			return
+		}
+	}
+	// Keep the anchor, so that aliases to the value still resolve, and the comments.
+	*n = yaml.Node{
+		Kind:        yaml.ScalarNode,
+		Tag:         "!!null",
+		Value:       "null",
+		Anchor:      n.Anchor,
+		HeadComment: n.HeadComment,
+		LineComment: n.LineComment,
+		FootComment: n.FootComment,
+	}
+}
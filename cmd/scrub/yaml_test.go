@@ -0,0 +1,110 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/acj/scrub"
+)
+
+func TestScrubYAML(t *testing.T) {
+	const input = `# Exported users
+users:
+  - name: Testy Tester
+    email: testy@example.com # primary
+    password: hunter2
+    pin: 1234
+    admin: true
+    address: &home
+      street: 742 Evergreen Terrace
+      city: Springfield
+    billing: *home
+---
+api_token: abc123
+`
+
+	t.Run("with rules, scrubs the selected values and keeps comments and order", func(t *testing.T) {
+		var out bytes.Buffer
+		err := scrubYAML(&out, strings.NewReader(input), []scrub.Rule{
+			{Name: "email", Action: scrub.Mask},
+			{Name: "password", Action: scrub.Omit},
+			{Path: "users.pin"},
+			{Path: "users.admin"},
+			{Path: "users.address.street", Action: scrub.Hash},
+			{Glob: "*_token"},
+		})
+		require.NoError(t, err)
+
+		assert.Equal(t, `# Exported users
+users:
+  - name: Testy Tester
+    email: '****' # primary
+    pin: 0
+    admin: false
+    address: &home
+      street: `+scrub.Hash.Replace("742 Evergreen Terrace")+`
+      city: Springfield
+    billing: *home
+---
+api_token: ""
+`, out.String())
+	})
+
+	t.Run("with a selected mapping, replaces it with null", func(t *testing.T) {
+		var out bytes.Buffer
+		err := scrubYAML(&out, strings.NewReader(input), []scrub.Rule{{Name: "users"}})
+		require.NoError(t, err)
+
+		assert.Equal(t, "# Exported users\nusers: null\n---\napi_token: abc123\n", out.String())
+	})
+
+	t.Run("with a rule for a path through an alias, scrubs a copy of the anchor's value", func(t *testing.T) {
+		var out bytes.Buffer
+		err := scrubYAML(&out, strings.NewReader(`base: &b {password: hunter2, user: testy}
+other: *b
+merged:
+  <<: *b
+  port: 5432
+same: *b
+`), []scrub.Rule{{Path: "other.password", Action: scrub.Mask}, {Path: "merged.password", Action: scrub.Omit}})
+		require.NoError(t, err)
+
+		assert.Equal(t, `base: &b {password: hunter2, user: testy}
+other: {password: '****', user: testy}
+merged:
+  !!merge <<: {user: testy}
+  port: 5432
+same: *b
+`, out.String())
+	})
+
+	t.Run("with an alias of a removed anchor, replaces it with null", func(t *testing.T) {
+		var out bytes.Buffer
+		err := scrubYAML(&out, strings.NewReader("secrets:\n  db: &db {password: hunter2}\nbackup: *db\n"),
+			[]scrub.Rule{{Name: "secrets", Action: scrub.Omit}})
+		require.NoError(t, err)
+
+		assert.Equal(t, "backup: null\n", out.String())
+	})
+
+	t.Run("with aliases that expand exponentially, returns an error", func(t *testing.T) {
+		doc := "a0: &a0 [x, x]\n"
+		for i := 1; i <= 30; i++ {
+			doc += fmt.Sprintf("a%d: &a%d [*a%d, *a%d]\n", i, i, i-1, i-1)
+		}
+		var out bytes.Buffer
+		err := scrubYAML(&out, strings.NewReader(doc), []scrub.Rule{{Name: "x"}})
+		assert.ErrorContains(t, err, "expands to more than")
+	})
+
+	t.Run("with invalid YAML, returns an error", func(t *testing.T) {
+		var out bytes.Buffer
+		err := scrubYAML(&out, strings.NewReader("a: [1, 2"), nil)
+		assert.Error(t, err)
+	})
+}
@@ -0,0 +1,709 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"go/format"
+	"go/types"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"sort"
+	"strings"
+
+	"golang.org/x/tools/go/packages"
+)
+
+type generated struct {
+	dir  string
+	code []byte
+	test []byte
+}
+
+// generate loads the package matching pattern and produces the ScrubTagged methods for the named types, or
+// for every struct type that needs scrubbing if typeNames is empty. The names of the generated functions
+// other than the methods are derived from output, the name of the file the code is written to, so that
+// files generated for the same package don't clash.
+func generate(pattern string, typeNames []string, output string) (*generated, error) {
+	cfg := &packages.Config{
+		Mode: packages.NeedName | packages.NeedFiles | packages.NeedTypes | packages.NeedSyntax | packages.NeedImports | packages.NeedDeps,
+	}
+	pkgs, err := packages.Load(cfg, pattern)
+	if err != nil {
+		return nil, err
+	}
+	if len(pkgs) != 1 {
+		return nil, fmt.Errorf("expected exactly one package matching %q, found %d", pattern, len(pkgs))
+	}
+	pkg := pkgs[0]
+	if len(pkg.Errors) > 0 {
+		return nil, pkg.Errors[0]
+	}
+	if len(pkg.GoFiles) == 0 {
+		return nil, fmt.Errorf("package %s has no Go files", pkg.PkgPath)
+	}
+
+	g := newGenerator(pkg.Types, nameSuffix(output))
+	targets, err := g.selectTargets(typeNames)
+	if err != nil {
+		return nil, err
+	}
+	if len(targets) == 0 {
+		return nil, fmt.Errorf("no struct types with scrub tags found in package %s", pkg.PkgPath)
+	}
+
+	code, err := g.code(targets)
+	if err != nil {
+		return nil, err
+	}
+	test, err := g.test(targets)
+	if err != nil {
+		return nil, err
+	}
+
+	return &generated{
+		dir:  filepath.Dir(pkg.GoFiles[0]),
+		code: code,
+		test: test,
+	}, nil
+}
+
+type generator struct {
+	pkg *types.Package
+	// suffix is appended to the names of generated functions, so that they're unique to the output file.
+	suffix string
+
+	// needs records whether a named struct type has tagged fields, directly or through nested values.
+	needs map[*types.Named]bool
+
+	targets     map[*types.Named]bool
+	helpers     []*types.Named
+	helperNames map[*types.Named]string
+
+	// inlining holds the types from other packages that can't be named here and whose fields are being
+	// emitted in place of a helper call. usesZero records whether the code needs scrubgenZero, which zeroes
+	// values of such types.
+	inlining map[*types.Named]bool
+	usesZero bool
+
+	importNames map[string]string // path -> local name
+	importPaths map[string]string // local name -> path
+}
+
+func newGenerator(pkg *types.Package, suffix string) *generator {
+	return &generator{
+		pkg:         pkg,
+		suffix:      suffix,
+		needs:       map[*types.Named]bool{},
+		targets:     map[*types.Named]bool{},
+		helperNames: map[*types.Named]string{},
+		inlining:    map[*types.Named]bool{},
+		importNames: map[string]string{},
+		importPaths: map[string]string{},
+	}
+}
+
+// selectTargets returns the package-level struct types that will get a ScrubTagged method.
+func (g *generator) selectTargets(typeNames []string) ([]*types.Named, error) {
+	scope := g.pkg.Scope()
+
+	var candidates []*types.Named
+	for _, name := range scope.Names() {
+		tn, ok := scope.Lookup(name).(*types.TypeName)
+		if !ok || tn.IsAlias() {
+			continue
+		}
+		named, ok := tn.Type().(*types.Named)
+		if !ok || named.TypeParams().Len() > 0 {
+			continue
+		}
+		if _, ok := named.Underlying().(*types.Struct); !ok {
+			continue
+		}
+		candidates = append(candidates, named)
+	}
+	g.resolveNeeds(candidates)
+
+	var targets []*types.Named
+	if len(typeNames) == 0 {
+		for _, named := range candidates {
+			if g.needs[named] {
+				targets = append(targets, named)
+			}
+		}
+	} else {
+		for _, name := range typeNames {
+			name = strings.TrimSpace(name)
+			i := slices.IndexFunc(candidates, func(n *types.Named) bool { return n.Obj().Name() == name })
+			if i < 0 {
+				return nil, fmt.Errorf("type %s not found or not a non-generic struct type", name)
+			}
+			targets = append(targets, candidates[i])
+		}
+	}
+
+	for _, named := range targets {
+		g.targets[named] = true
+	}
+	return targets, nil
+}
+
+// resolveNeeds computes g.needs for every named struct type reachable from roots. Recursive types are
+// handled by iterating to a fixed point.
+func (g *generator) resolveNeeds(roots []*types.Named) {
+	var reachable []*types.Named
+	seen := map[*types.Named]bool{}
+	var visit func(t types.Type)
+	visit = func(t types.Type) {
+		st, named := structOf(t)
+		if st == nil {
+			return
+		}
+		if named != nil {
+			if seen[named] {
+				return
+			}
+			seen[named] = true
+			reachable = append(reachable, named)
+		}
+		for i := 0; i < st.NumFields(); i++ {
+			if f := st.Field(i); f.Exported() {
+				visit(walkedStruct(f.Type()))
+			}
+		}
+	}
+	for _, root := range roots {
+		visit(root)
+	}
+
+	for changed := true; changed; {
+		changed = false
+		for _, named := range reachable {
+			if !g.needs[named] && g.structNeeds(named.Underlying().(*types.Struct)) {
+				g.needs[named] = true
+				changed = true
+			}
+		}
+	}
+}
+
+func (g *generator) structNeeds(st *types.Struct) bool {
+	for i := 0; i < st.NumFields(); i++ {
+		f := st.Field(i)
+		if !f.Exported() {
+			continue
+		}
+		if isTagged(st.Tag(i)) || g.typeNeeds(walkedStruct(f.Type())) {
+			return true
+		}
+	}
+	return false
+}
+
+func (g *generator) typeNeeds(t types.Type) bool {
+	st, named := structOf(t)
+	if st == nil {
+		return false
+	}
+	if named != nil {
+		return g.needs[named]
+	}
+	return g.structNeeds(st)
+}
+
+func (g *generator) code(targets []*types.Named) ([]byte, error) {
+	var body bytes.Buffer
+	for _, named := range targets {
+		fmt.Fprintf(&body, "// ScrubTagged sets all fields of v annotated with a `scrub:\"true\"` struct tag to their zero value,\n")
+		fmt.Fprintf(&body, "// recursively. It is a reflection-free equivalent of scrub.TaggedFields(v).\n")
+		fmt.Fprintf(&body, "func (v *%s) ScrubTagged() {\n", named.Obj().Name())
+		g.emitBody(&body, named)
+		fmt.Fprintf(&body, "}\n\n")
+	}
+	// Helpers can queue further helpers while being emitted.
+	for i := 0; i < len(g.helpers); i++ {
+		named := g.helpers[i]
+		fmt.Fprintf(&body, "func %s(v *%s) {\n", g.helperNames[named], g.typeString(named))
+		g.emitBody(&body, named)
+		fmt.Fprintf(&body, "}\n\n")
+	}
+	if g.usesZero {
+		body.WriteString(strings.ReplaceAll(zeroHelper, "scrubgenZero", "scrubgenZero"+g.suffix))
+	}
+
+	var out bytes.Buffer
+	fmt.Fprintf(&out, "// Code generated by scrubgen. DO NOT EDIT.\n\n")
+	fmt.Fprintf(&out, "package %s\n\n", g.pkg.Name())
+	g.writeImports(&out)
+	out.Write(body.Bytes())
+	return formatSource(out.Bytes())
+}
+
+func (g *generator) emitBody(w *bytes.Buffer, named *types.Named) {
+	fmt.Fprintf(w, "if v == nil {\nreturn\n}\n")
+	g.emitFields(w, "v", named.Underlying().(*types.Struct), 0)
+}
+
+func (g *generator) emitFields(w *bytes.Buffer, expr string, st *types.Struct, depth int) {
+	for i := 0; i < st.NumFields(); i++ {
+		f := st.Field(i)
+		if !f.Exported() {
+			continue
+		}
+		fieldExpr := expr + "." + f.Name()
+		if isTagged(st.Tag(i)) {
+			// Zero values of struct and array types are written as literals, which name the type.
+			if !g.nameable(f.Type()) && isStructOrArray(f.Type()) {
+				fmt.Fprintf(w, "scrubgenZero%s(&%s)\n", g.suffix, fieldExpr)
+				g.usesZero = true
+			} else {
+				fmt.Fprintf(w, "%s = %s\n", fieldExpr, g.zero(f.Type()))
+			}
+			continue
+		}
+		g.emitWalk(w, fieldExpr, f.Type(), depth)
+	}
+}
+
+// emitWalk emits code that scrubs the untagged value expr of type t, mirroring the traversal done by
+// scrub.TaggedFields: structs, pointers to structs, and slices of either are walked; everything else
+// is left alone.
+func (g *generator) emitWalk(w *bytes.Buffer, expr string, t types.Type, depth int) {
+	switch u := t.Underlying().(type) {
+	case *types.Struct:
+		if g.typeNeeds(t) {
+			g.emitStruct(w, expr, t, false, depth)
+		}
+	case *types.Pointer:
+		if isStruct(u.Elem()) && g.typeNeeds(u.Elem()) {
+			g.emitStruct(w, g.pointerExpr(expr, t), u.Elem(), true, depth)
+		}
+	case *types.Slice:
+		elem := u.Elem()
+		var isPtr bool
+		if p, ok := elem.Underlying().(*types.Pointer); ok {
+			elem, isPtr = p.Elem(), true
+		}
+		if !isStruct(elem) || !g.typeNeeds(elem) {
+			return
+		}
+		index := loopVar(depth)
+		elemExpr := fmt.Sprintf("%s[%s]", expr, index)
+		if isPtr {
+			elemExpr = g.pointerExpr(elemExpr, u.Elem())
+		}
+		fmt.Fprintf(w, "for %s := range %s {\n", index, expr)
+		g.emitStruct(w, elemExpr, elem, isPtr, depth+1)
+		fmt.Fprintf(w, "}\n")
+	}
+}
+
+// emitStruct emits code that scrubs the struct (or, if isPtr, the pointer to struct) expr of type t.
+func (g *generator) emitStruct(w *bytes.Buffer, expr string, t types.Type, isPtr bool, depth int) {
+	st, named := structOf(t)
+	if named == nil {
+		if isPtr {
+			fmt.Fprintf(w, "if %s != nil {\n", expr)
+			g.emitFields(w, expr, st, depth)
+			fmt.Fprintf(w, "}\n")
+			return
+		}
+		g.emitFields(w, expr, st, depth)
+		return
+	}
+	if g.targets[named] {
+		fmt.Fprintf(w, "%s.ScrubTagged()\n", expr)
+		return
+	}
+	if !g.nameable(named) {
+		g.emitInline(w, expr, named, st, isPtr, depth)
+		return
+	}
+	if !isPtr {
+		expr = "&" + expr
+	}
+	fmt.Fprintf(w, "%s(%s)\n", g.helper(named), expr)
+}
+
+// emitInline emits the fields of a type from another package that can't be named here, such as an
+// unexported type of an exported field, in place, since a helper would have to name it. A type that
+// contains itself can't be inlined, so it's scrubbed with scrub.TaggedFields instead.
+func (g *generator) emitInline(w *bytes.Buffer, expr string, named *types.Named, st *types.Struct, isPtr bool, depth int) {
+	if g.inlining[named] {
+		if !isPtr {
+			expr = "&" + expr
+		}
+		fmt.Fprintf(w, "%sTaggedFields(%s)\n", g.qualifiedPrefix(scrubPackage), expr)
+		return
+	}
+	g.inlining[named] = true
+	defer delete(g.inlining, named)
+	if isPtr {
+		fmt.Fprintf(w, "if %s != nil {\n", expr)
+		g.emitFields(w, expr, st, depth)
+		fmt.Fprintf(w, "}\n")
+		return
+	}
+	g.emitFields(w, expr, st, depth)
+}
+
+var scrubPackage = types.NewPackage("github.com/acj/scrub", "scrub")
+
+// nameable reports whether t can be written in the generated code, which it can't if it refers to an
+// unexported type, or a struct with unexported fields, from another package.
+func (g *generator) nameable(t types.Type) bool {
+	switch t := types.Unalias(t).(type) {
+	case *types.Named:
+		if t.Obj().Pkg() != nil && t.Obj().Pkg() != g.pkg && !t.Obj().Exported() {
+			return false
+		}
+		return !slices.ContainsFunc(typeArgs(t), func(arg types.Type) bool { return !g.nameable(arg) })
+	case *types.Pointer:
+		return g.nameable(t.Elem())
+	case *types.Slice:
+		return g.nameable(t.Elem())
+	case *types.Array:
+		return g.nameable(t.Elem())
+	case *types.Chan:
+		return g.nameable(t.Elem())
+	case *types.Map:
+		return g.nameable(t.Key()) && g.nameable(t.Elem())
+	case *types.Struct:
+		for i := 0; i < t.NumFields(); i++ {
+			f := t.Field(i)
+			if !f.Exported() && f.Pkg() != g.pkg || !g.nameable(f.Type()) {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// pointerExpr converts expr to an unnamed pointer type if t is a defined pointer type, since methods
+// can't be called through those.
+func (g *generator) pointerExpr(expr string, t types.Type) string {
+	if _, ok := types.Unalias(t).(*types.Named); !ok {
+		return expr
+	}
+	p := t.Underlying().(*types.Pointer)
+	return fmt.Sprintf("(%s)(%s)", g.typeString(p), expr)
+}
+
+func (g *generator) helper(named *types.Named) string {
+	if name, ok := g.helperNames[named]; ok {
+		return name
+	}
+	var sb strings.Builder
+	sb.WriteString("scrubTagged")
+	if named.Obj().Pkg() != g.pkg {
+		sb.WriteString(exportedName(named.Obj().Pkg().Name()))
+	}
+	sb.WriteString(exportedName(named.Obj().Name()))
+	for _, arg := range typeArgs(named) {
+		sb.WriteString(exportedName(identifier(types.TypeString(arg, func(p *types.Package) string { return p.Name() }))))
+	}
+	sb.WriteString(g.suffix)
+	name := sb.String()
+	for i := 2; slices.Contains(mapValues(g.helperNames), name); i++ {
+		name = fmt.Sprintf("%s%d", sb.String(), i)
+	}
+	g.helperNames[named] = name
+	g.helpers = append(g.helpers, named)
+	return name
+}
+
+func (g *generator) zero(t types.Type) string {
+	switch u := t.Underlying().(type) {
+	case *types.Basic:
+		switch {
+		case u.Info()&types.IsBoolean != 0:
+			return "false"
+		case u.Info()&types.IsString != 0:
+			return `""`
+		case u.Info()&types.IsNumeric != 0:
+			return "0"
+		default:
+			return "nil"
+		}
+	case *types.Struct, *types.Array:
+		return g.typeString(t) + "{}"
+	default:
+		return "nil"
+	}
+}
+
+func (g *generator) typeString(t types.Type) string {
+	return types.TypeString(t, g.qualifier)
+}
+
+// qualifiedPrefix returns the prefix for names from p, such as "scrub.", or "" for names from g.pkg.
+func (g *generator) qualifiedPrefix(p *types.Package) string {
+	if name := g.qualifier(p); name != "" {
+		return name + "."
+	}
+	return ""
+}
+
+func (g *generator) qualifier(p *types.Package) string {
+	if p.Path() == g.pkg.Path() {
+		return ""
+	}
+	if name, ok := g.importNames[p.Path()]; ok {
+		return name
+	}
+	name := p.Name()
+	for i := 2; g.importPaths[name] != "" || g.pkg.Scope().Lookup(name) != nil; i++ {
+		name = fmt.Sprintf("%s%d", p.Name(), i)
+	}
+	g.importNames[p.Path()] = name
+	g.importPaths[name] = p.Path()
+	return name
+}
+
+func (g *generator) writeImports(w *bytes.Buffer) {
+	if len(g.importNames) == 0 {
+		return
+	}
+	paths := mapKeys(g.importNames)
+	sort.Strings(paths)
+	fmt.Fprintf(w, "import (\n")
+	for _, path := range paths {
+		name := g.importNames[path]
+		if name == lastElem(path) {
+			fmt.Fprintf(w, "%q\n", path)
+		} else {
+			fmt.Fprintf(w, "%s %q\n", name, path)
+		}
+	}
+	fmt.Fprintf(w, ")\n\n")
+}
+
+func (g *generator) test(targets []*types.Named) ([]byte, error) {
+	var out bytes.Buffer
+	fmt.Fprintf(&out, "// Code generated by scrubgen. DO NOT EDIT.\n\n")
+	fmt.Fprintf(&out, "package %s\n\n", g.pkg.Name())
+	out.WriteString(testImports)
+	fmt.Fprintf(&out, "func TestScrubTaggedMatchesTaggedFields%s(t *testing.T) {\n", g.suffix)
+	for _, named := range targets {
+		fmt.Fprintf(&out, testCase, named.Obj().Name(), g.suffix)
+	}
+	fmt.Fprintf(&out, "}\n\n")
+	out.WriteString(strings.ReplaceAll(testFill, "scrubgenFill", "scrubgenFill"+g.suffix))
+	return formatSource(out.Bytes())
+}
+
+const zeroHelper = `// scrubgenZero sets *p to its zero value, for fields whose types can't be named in this package.
+func scrubgenZero[T any](p *T) {
+	var zero T
+	*p = zero
+}
+
+`
+
+const testImports = `import (
+	"math/rand"
+	"reflect"
+	"strconv"
+	"testing"
+
+	"github.com/acj/scrub"
+)
+
+`
+
+const testCase = `t.Run(%[1]q, func(t *testing.T) {
+	var nilValue *%[1]s
+	nilValue.ScrubTagged()
+
+	for seed := int64(0); seed < 100; seed++ {
+		var expected, actual %[1]s
+		scrubgenFill%[2]s(reflect.ValueOf(&expected).Elem(), rand.New(rand.NewSource(seed)), 0)
+		scrubgenFill%[2]s(reflect.ValueOf(&actual).Elem(), rand.New(rand.NewSource(seed)), 0)
+		scrub.TaggedFields(&expected)
+		actual.ScrubTagged()
+		if !reflect.DeepEqual(expected, actual) {
+			t.Fatalf("seed %%d: ScrubTagged() = %%+v, want %%+v", seed, actual, expected)
+		}
+	}
+})
+`
+
+const testFill = `// scrubgenFill populates v with pseudo-random data derived from r. Unexported fields are left alone.
+func scrubgenFill(v reflect.Value, r *rand.Rand, depth int) {
+	const maxDepth = 4
+	switch v.Kind() {
+	case reflect.Bool:
+		v.SetBool(r.Intn(2) == 1)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		v.SetInt(r.Int63n(100) + 1)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		v.SetUint(uint64(r.Int63n(100) + 1))
+	case reflect.Float32, reflect.Float64:
+		v.SetFloat(r.Float64() + 1)
+	case reflect.Complex64, reflect.Complex128:
+		v.SetComplex(complex(r.Float64()+1, r.Float64()+1))
+	case reflect.String:
+		v.SetString(strconv.FormatInt(r.Int63(), 36))
+	case reflect.Ptr:
+		if depth < maxDepth && r.Intn(4) > 0 {
+			v.Set(reflect.New(v.Type().Elem()))
+			scrubgenFill(v.Elem(), r, depth+1)
+		}
+	case reflect.Slice:
+		if depth < maxDepth && r.Intn(4) > 0 {
+			n := r.Intn(3) + 1
+			v.Set(reflect.MakeSlice(v.Type(), n, n))
+			for i := 0; i < n; i++ {
+				scrubgenFill(v.Index(i), r, depth+1)
+			}
+		}
+	case reflect.Array:
+		for i := 0; i < v.Len(); i++ {
+			scrubgenFill(v.Index(i), r, depth+1)
+		}
+	case reflect.Map:
+		if depth < maxDepth && r.Intn(4) > 0 {
+			v.Set(reflect.MakeMap(v.Type()))
+			for i := r.Intn(3) + 1; i > 0; i-- {
+				key := reflect.New(v.Type().Key()).Elem()
+				elem := reflect.New(v.Type().Elem()).Elem()
+				scrubgenFill(key, r, depth+1)
+				scrubgenFill(elem, r, depth+1)
+				v.SetMapIndex(key, elem)
+			}
+		}
+	case reflect.Struct:
+		for i := 0; i < v.NumField(); i++ {
+			if v.Field(i).CanSet() {
+				scrubgenFill(v.Field(i), r, depth)
+			}
+		}
+	}
+}
+`
+
+func formatSource(src []byte) ([]byte, error) {
+	formatted, err := format.Source(src)
+	if err != nil {
+		return nil, fmt.Errorf("formatting generated code: %w\n%s", err, src)
+	}
+	return formatted, nil
+}
+
+func isTagged(tag string) bool {
+	return reflect.StructTag(tag).Get("scrub") == "true"
+}
+
+func isStruct(t types.Type) bool {
+	_, ok := t.Underlying().(*types.Struct)
+	return ok
+}
+
+func isStructOrArray(t types.Type) bool {
+	switch t.Underlying().(type) {
+	case *types.Struct, *types.Array:
+		return true
+	}
+	return false
+}
+
+// structOf returns the struct underlying t and, if t is a named type, the named type itself.
+func structOf(t types.Type) (*types.Struct, *types.Named) {
+	if t == nil {
+		return nil, nil
+	}
+	st, ok := t.Underlying().(*types.Struct)
+	if !ok {
+		return nil, nil
+	}
+	named, _ := types.Unalias(t).(*types.Named)
+	return st, named
+}
+
+// walkedStruct returns the struct type that TaggedFields would descend into for a field of type t, or
+// nil if it wouldn't descend.
+func walkedStruct(t types.Type) types.Type {
+	switch u := t.Underlying().(type) {
+	case *types.Struct:
+		return t
+	case *types.Pointer:
+		if isStruct(u.Elem()) {
+			return u.Elem()
+		}
+	case *types.Slice:
+		elem := u.Elem()
+		if p, ok := elem.Underlying().(*types.Pointer); ok {
+			elem = p.Elem()
+		}
+		if isStruct(elem) {
+			return elem
+		}
+	}
+	return nil
+}
+
+func typeArgs(named *types.Named) []types.Type {
+	var args []types.Type
+	for i := 0; i < named.TypeArgs().Len(); i++ {
+		args = append(args, named.TypeArgs().At(i))
+	}
+	return args
+}
+
+// nameSuffix returns the suffix for the names of the functions generated into the file named output: none
+// for the default scrub_gen.go, and otherwise the file's base name in camel case, as in "AccountScrub" for
+// account_scrub.go.
+func nameSuffix(output string) string {
+	base := strings.TrimSuffix(filepath.Base(output), ".go")
+	if base == "scrub_gen" {
+		return ""
+	}
+	var sb strings.Builder
+	for _, part := range strings.FieldsFunc(base, func(r rune) bool { return identifier(string(r)) == "" || r == '_' }) {
+		sb.WriteString(exportedName(part))
+	}
+	return sb.String()
+}
+
+func loopVar(depth int) string {
+	if vars := []string{"i", "j", "k"}; depth < len(vars) {
+		return vars[depth]
+	}
+	return fmt.Sprintf("i%d", depth)
+}
+
+func exportedName(s string) string {
+	if s == "" {
+		return s
+	}
+	return strings.ToUpper(s[:1]) + s[1:]
+}
+
+func identifier(s string) string {
+	return strings.Map(func(r rune) rune {
+		if r == '_' || r >= '0' && r <= '9' || r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' {
+			return r
+		}
+		return -1
+	}, s)
+}
+
+func lastElem(path string) string {
+	return path[strings.LastIndex(path, "/")+1:]
+}
+
+func mapKeys[K comparable, V any](m map[K]V) []K {
+	keys := make([]K, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	return keys
+}
+
+func mapValues[K comparable, V any](m map[K]V) []V {
+	values := make([]V, 0, len(m))
+	for _, v := range m {
+		values = append(values, v)
+	}
+	return values
+}
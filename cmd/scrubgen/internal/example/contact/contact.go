@@ -0,0 +1,36 @@
+// Package contact provides types that are embedded in the example package, to exercise how scrubgen handles
+// struct types from other packages.
+package contact
+
+type Address struct {
+	Street string `scrub:"true"`
+	City   string
+	Geo    *Geo
+}
+
+type Geo struct {
+	Latitude  float64 `scrub:"true"`
+	Longitude float64 `scrub:"true"`
+}
+
+type Phone struct {
+	Number  string `scrub:"true"`
+	Country string
+}
+
+// Badge is exported, but the types of its fields aren't, so scrubgen can't name them in generated code.
+type Badge struct {
+	Card  card
+	Spare *card
+}
+
+type card struct {
+	Number string `scrub:"true"`
+	PIN    pin    `scrub:"true"`
+	Issuer string
+	Next   *card
+}
+
+type pin struct {
+	Digits string
+}
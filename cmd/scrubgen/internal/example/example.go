@@ -0,0 +1,52 @@
+// Package example exercises scrubgen against the kinds of types it supports. The generated code is checked
+// in so that the equivalence test runs as part of the normal test suite.
+package example
+
+import (
+	"time"
+
+	"github.com/acj/scrub/cmd/scrubgen/internal/example/contact"
+)
+
+//go:generate go run github.com/acj/scrub/cmd/scrubgen
+
+type Account struct {
+	ID       int
+	Email    string `scrub:"true"`
+	Password string `scrub:"true"`
+	Owner    *User
+	Members  []User
+	Admins   []*User
+	Billing  contact.Address
+	Shipping *contact.Address
+	Tokens   []string          `scrub:"true"`
+	Labels   map[string]string `scrub:"true"`
+	Settings struct {
+		Theme  string
+		APIKey string `scrub:"true"`
+	}
+	Created time.Time
+	notes   string
+}
+
+type User struct {
+	Name    string
+	SSN     string `scrub:"true"`
+	Manager *User
+	Secret  *string         `scrub:"true"`
+	Home    contact.Address `scrub:"true"`
+	Badge   contact.Badge
+	contact.Phone
+}
+
+// Team only needs scrubbing because of the types it contains.
+type Team struct {
+	Name    string
+	Lead    User
+	Members []*User
+}
+
+// Plain has no tagged fields and doesn't get a ScrubTagged method.
+type Plain struct {
+	Name string
+}
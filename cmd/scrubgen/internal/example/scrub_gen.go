@@ -0,0 +1,99 @@
+// Code generated by scrubgen. DO NOT EDIT.
+
+package example
+
+import (
+	"github.com/acj/scrub"
+	"github.com/acj/scrub/cmd/scrubgen/internal/example/contact"
+)
+
+// ScrubTagged sets all fields of v annotated with a `scrub:"true"` struct tag to their zero value,
+// recursively. It is a reflection-free equivalent of scrub.TaggedFields(v).
+func (v *Account) ScrubTagged() {
+	if v == nil {
+		return
+	}
+	v.Email = ""
+	v.Password = ""
+	v.Owner.ScrubTagged()
+	for i := range v.Members {
+		v.Members[i].ScrubTagged()
+	}
+	for i := range v.Admins {
+		v.Admins[i].ScrubTagged()
+	}
+	scrubTaggedContactAddress(&v.Billing)
+	scrubTaggedContactAddress(v.Shipping)
+	v.Tokens = nil
+	v.Labels = nil
+	v.Settings.APIKey = ""
+}
+
+// ScrubTagged sets all fields of v annotated with a `scrub:"true"` struct tag to their zero value,
+// recursively. It is a reflection-free equivalent of scrub.TaggedFields(v).
+func (v *Team) ScrubTagged() {
+	if v == nil {
+		return
+	}
+	v.Lead.ScrubTagged()
+	for i := range v.Members {
+		v.Members[i].ScrubTagged()
+	}
+}
+
+// ScrubTagged sets all fields of v annotated with a `scrub:"true"` struct tag to their zero value,
+// recursively. It is a reflection-free equivalent of scrub.TaggedFields(v).
+func (v *User) ScrubTagged() {
+	if v == nil {
+		return
+	}
+	v.SSN = ""
+	v.Manager.ScrubTagged()
+	v.Secret = nil
+	v.Home = contact.Address{}
+	scrubTaggedContactBadge(&v.Badge)
+	scrubTaggedContactPhone(&v.Phone)
+}
+
+func scrubTaggedContactAddress(v *contact.Address) {
+	if v == nil {
+		return
+	}
+	v.Street = ""
+	scrubTaggedContactGeo(v.Geo)
+}
+
+func scrubTaggedContactBadge(v *contact.Badge) {
+	if v == nil {
+		return
+	}
+	v.Card.Number = ""
+	scrubgenZero(&v.Card.PIN)
+	scrub.TaggedFields(v.Card.Next)
+	if v.Spare != nil {
+		v.Spare.Number = ""
+		scrubgenZero(&v.Spare.PIN)
+		scrub.TaggedFields(v.Spare.Next)
+	}
+}
+
+func scrubTaggedContactPhone(v *contact.Phone) {
+	if v == nil {
+		return
+	}
+	v.Number = ""
+}
+
+func scrubTaggedContactGeo(v *contact.Geo) {
+	if v == nil {
+		return
+	}
+	v.Latitude = 0
+	v.Longitude = 0
+}
+
+// scrubgenZero sets *p to its zero value, for fields whose types can't be named in this package.
+func scrubgenZero[T any](p *T) {
+	var zero T
+	*p = zero
+}
@@ -0,0 +1,113 @@
+// Code generated by scrubgen. DO NOT EDIT.
+
+package example
+
+import (
+	"math/rand"
+	"reflect"
+	"strconv"
+	"testing"
+
+	"github.com/acj/scrub"
+)
+
+func TestScrubTaggedMatchesTaggedFields(t *testing.T) {
+	t.Run("Account", func(t *testing.T) {
+		var nilValue *Account
+		nilValue.ScrubTagged()
+
+		for seed := int64(0); seed < 100; seed++ {
+			var expected, actual Account
+			scrubgenFill(reflect.ValueOf(&expected).Elem(), rand.New(rand.NewSource(seed)), 0)
+			scrubgenFill(reflect.ValueOf(&actual).Elem(), rand.New(rand.NewSource(seed)), 0)
+			scrub.TaggedFields(&expected)
+			actual.ScrubTagged()
+			if !reflect.DeepEqual(expected, actual) {
+				t.Fatalf("seed %d: ScrubTagged() = %+v, want %+v", seed, actual, expected)
+			}
+		}
+	})
+	t.Run("Team", func(t *testing.T) {
+		var nilValue *Team
+		nilValue.ScrubTagged()
+
+		for seed := int64(0); seed < 100; seed++ {
+			var expected, actual Team
+			scrubgenFill(reflect.ValueOf(&expected).Elem(), rand.New(rand.NewSource(seed)), 0)
+			scrubgenFill(reflect.ValueOf(&actual).Elem(), rand.New(rand.NewSource(seed)), 0)
+			scrub.TaggedFields(&expected)
+			actual.ScrubTagged()
+			if !reflect.DeepEqual(expected, actual) {
+				t.Fatalf("seed %d: ScrubTagged() = %+v, want %+v", seed, actual, expected)
+			}
+		}
+	})
+	t.Run("User", func(t *testing.T) {
+		var nilValue *User
+		nilValue.ScrubTagged()
+
+		for seed := int64(0); seed < 100; seed++ {
+			var expected, actual User
+			scrubgenFill(reflect.ValueOf(&expected).Elem(), rand.New(rand.NewSource(seed)), 0)
+			scrubgenFill(reflect.ValueOf(&actual).Elem(), rand.New(rand.NewSource(seed)), 0)
+			scrub.TaggedFields(&expected)
+			actual.ScrubTagged()
+			if !reflect.DeepEqual(expected, actual) {
+				t.Fatalf("seed %d: ScrubTagged() = %+v, want %+v", seed, actual, expected)
+			}
+		}
+	})
+}
+
+// scrubgenFill populates v with pseudo-random data derived from r. Unexported fields are left alone.
+func scrubgenFill(v reflect.Value, r *rand.Rand, depth int) {
+	const maxDepth = 4
+	switch v.Kind() {
+	case reflect.Bool:
+		v.SetBool(r.Intn(2) == 1)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		v.SetInt(r.Int63n(100) + 1)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		v.SetUint(uint64(r.Int63n(100) + 1))
+	case reflect.Float32, reflect.Float64:
+		v.SetFloat(r.Float64() + 1)
+	case reflect.Complex64, reflect.Complex128:
+		v.SetComplex(complex(r.Float64()+1, r.Float64()+1))
+	case reflect.String:
+		v.SetString(strconv.FormatInt(r.Int63(), 36))
+	case reflect.Ptr:
+		if depth < maxDepth && r.Intn(4) > 0 {
+			v.Set(reflect.New(v.Type().Elem()))
+			scrubgenFill(v.Elem(), r, depth+1)
+		}
+	case reflect.Slice:
+		if depth < maxDepth && r.Intn(4) > 0 {
+			n := r.Intn(3) + 1
+			v.Set(reflect.MakeSlice(v.Type(), n, n))
+			for i := 0; i < n; i++ {
+				scrubgenFill(v.Index(i), r, depth+1)
+			}
+		}
+	case reflect.Array:
+		for i := 0; i < v.Len(); i++ {
+			scrubgenFill(v.Index(i), r, depth+1)
+		}
+	case reflect.Map:
+		if depth < maxDepth && r.Intn(4) > 0 {
+			v.Set(reflect.MakeMap(v.Type()))
+			for i := r.Intn(3) + 1; i > 0; i-- {
+				key := reflect.New(v.Type().Key()).Elem()
+				elem := reflect.New(v.Type().Elem()).Elem()
+				scrubgenFill(key, r, depth+1)
+				scrubgenFill(elem, r, depth+1)
+				v.SetMapIndex(key, elem)
+			}
+		}
+	case reflect.Struct:
+		for i := 0; i < v.NumField(); i++ {
+			if v.Field(i).CanSet() {
+				scrubgenFill(v.Field(i), r, depth)
+			}
+		}
+	}
+}
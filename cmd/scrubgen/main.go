@@ -0,0 +1,94 @@
+// Command scrubgen generates reflection-free ScrubTagged methods for struct types.
+//
+// For each struct type in a package that has fields annotated with a `scrub:"true"` struct tag,
+// either directly or through nested structs, pointers to structs or slices of either, scrubgen emits
+// a method
+//
+//	func (v *T) ScrubTagged()
+//
+// that behaves exactly like scrub.TaggedFields(v) without using reflection. Nested struct types from
+// other packages are handled by generated helper functions. Unexported fields are left alone, as they
+// are by TaggedFields.
+//
+// scrubgen also emits a test that checks the generated methods against TaggedFields using randomly
+// populated values.
+//
+// To split the methods of a package across files, run scrubgen once per file with -type and -output. The
+// names of the helper functions and the test in each file are derived from the file's name, so they don't
+// clash.
+//
+// Typical usage is via go generate:
+//
+//	//go:generate go run github.com/acj/scrub/cmd/scrubgen
+//
+// Flags:
+//
+//	-type    comma-separated list of type names; defaults to every struct type that needs scrubbing
+//	-output  output file name; defaults to scrub_gen.go in the package directory
+//	-test    also write an equivalence test next to the output file (default true)
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// defaultOutput is the name of the file written in the package directory when -output isn't given.
+const defaultOutput = "scrub_gen.go"
+
+func main() {
+	typeNames := flag.String("type", "", "comma-separated list of type names; defaults to all struct types that need scrubbing")
+	output := flag.String("output", "", "output file name; defaults to scrub_gen.go in the package directory")
+	withTest := flag.Bool("test", true, "also write an equivalence test next to the output file")
+	flag.Usage = func() {
+		fmt.Fprintf(os.Stderr, "usage: scrubgen [flags] [package]\n")
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
+	pattern := "."
+	if flag.NArg() > 1 {
+		flag.Usage()
+		os.Exit(2)
+	}
+	if flag.NArg() == 1 {
+		pattern = flag.Arg(0)
+	}
+
+	var types []string
+	if *typeNames != "" {
+		types = strings.Split(*typeNames, ",")
+	}
+
+	if err := run(pattern, types, *output, *withTest); err != nil {
+		fmt.Fprintf(os.Stderr, "scrubgen: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+func run(pattern string, typeNames []string, output string, withTest bool) error {
+	name := defaultOutput
+	if output != "" {
+		name = filepath.Base(output)
+	}
+	result, err := generate(pattern, typeNames, name)
+	if err != nil {
+		return err
+	}
+	if output == "" {
+		output = filepath.Join(result.dir, defaultOutput)
+	}
+	if err := os.WriteFile(output, result.code, 0o644); err != nil {
+		return err
+	}
+	if withTest {
+		testOutput := strings.TrimSuffix(output, ".go") + "_test.go"
+		if err := os.WriteFile(testOutput, result.test, 0o644); err != nil {
+			return err
+		}
+	}
+	return nil
+}
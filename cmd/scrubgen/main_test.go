@@ -0,0 +1,79 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const examplePackage = "./internal/example"
+
+func TestGenerate(t *testing.T) {
+	t.Run("output matches the checked-in code for the example package", func(t *testing.T) {
+		result, err := generate(examplePackage, nil, defaultOutput)
+		require.NoError(t, err)
+
+		code, err := os.ReadFile(filepath.Join(examplePackage, "scrub_gen.go"))
+		require.NoError(t, err)
+		test, err := os.ReadFile(filepath.Join(examplePackage, "scrub_gen_test.go"))
+		require.NoError(t, err)
+
+		assert.Equal(t, string(code), string(result.code), "run go generate ./... to update")
+		assert.Equal(t, string(test), string(result.test), "run go generate ./... to update")
+	})
+
+	t.Run("with type names, only generates methods for those types", func(t *testing.T) {
+		result, err := generate(examplePackage, []string{"User"}, defaultOutput)
+		require.NoError(t, err)
+
+		assert.Contains(t, string(result.code), "func (v *User) ScrubTagged()")
+		assert.NotContains(t, string(result.code), "func (v *Account) ScrubTagged()")
+		assert.NotContains(t, string(result.code), "func (v *Team) ScrubTagged()")
+	})
+
+	t.Run("with a type that doesn't need scrubbing, generates a method anyway", func(t *testing.T) {
+		result, err := generate(examplePackage, []string{"Plain"}, defaultOutput)
+		require.NoError(t, err)
+
+		assert.Contains(t, string(result.code), "func (v *Plain) ScrubTagged()")
+	})
+
+	t.Run("with types that aren't method targets, generates helpers for them", func(t *testing.T) {
+		result, err := generate(examplePackage, []string{"Team"}, defaultOutput)
+		require.NoError(t, err)
+
+		assert.Contains(t, string(result.code), "func scrubTaggedUser(v *User)")
+		assert.Contains(t, string(result.code), "scrubTaggedUser(&v.Lead)")
+	})
+
+	t.Run("with fields of unexported types from another package, scrubs them in place", func(t *testing.T) {
+		result, err := generate(examplePackage, []string{"User"}, defaultOutput)
+		require.NoError(t, err)
+
+		assert.Contains(t, string(result.code), "v.Card.Number = \"\"\n")
+		assert.Contains(t, string(result.code), "scrubgenZero(&v.Card.PIN)\n")
+		assert.Contains(t, string(result.code), "scrub.TaggedFields(v.Card.Next)\n")
+		assert.NotContains(t, string(result.code), "contact.card")
+	})
+
+	t.Run("with another output file, derives the names of generated functions from it", func(t *testing.T) {
+		result, err := generate(examplePackage, []string{"Team", "User"}, "team_scrub.go")
+		require.NoError(t, err)
+
+		assert.Contains(t, string(result.code), "func scrubTaggedContactPhoneTeamScrub(v *contact.Phone)")
+		assert.Contains(t, string(result.code), "func scrubgenZeroTeamScrub[T any](p *T)")
+		assert.Contains(t, string(result.code), "scrubgenZeroTeamScrub(&v.Card.PIN)\n")
+		assert.Contains(t, string(result.test), "func TestScrubTaggedMatchesTaggedFieldsTeamScrub(t *testing.T)")
+		assert.Contains(t, string(result.test), "func scrubgenFillTeamScrub(v reflect.Value, r *rand.Rand, depth int)")
+		assert.NotContains(t, string(result.code)+string(result.test), "scrubgenZero(")
+		assert.NotContains(t, string(result.code)+string(result.test), "scrubgenFill(")
+	})
+
+	t.Run("with an unknown type name, returns an error", func(t *testing.T) {
+		_, err := generate(examplePackage, []string{"Missing"}, defaultOutput)
+		assert.ErrorContains(t, err, "type Missing not found")
+	})
+}
@@ -0,0 +1,86 @@
+package scrub
+
+import (
+	"reflect"
+)
+
+// Copy returns a deep copy of src, so that the copy can be scrubbed without affecting src. Pointers, slices,
+// maps, arrays and interfaces are copied recursively, and cycles and shared pointers are preserved.
+// Unexported fields are copied shallowly, so the copy shares whatever they point to with src. Channels and
+// functions are shared as well.
+func Copy[T any](src T) T {
+	v := reflect.ValueOf(&src).Elem()
+	dst := reflect.New(v.Type()).Elem()
+	c := copier{seen: map[pointer]reflect.Value{}}
+	c.copy(dst, v)
+	return *dst.Addr().Interface().(*T)
+}
+
+type pointer struct {
+	addr uintptr
+	typ  reflect.Type
+}
+
+type copier struct {
+	seen map[pointer]reflect.Value
+}
+
+// copy sets dst, which must be settable and have the same type as src, to a deep copy of src.
+func (c *copier) copy(dst, src reflect.Value) {
+	switch src.Kind() {
+	case reflect.Ptr:
+		if src.IsNil() {
+			return
+		}
+		key := pointer{addr: src.Pointer(), typ: src.Type()}
+		if p, ok := c.seen[key]; ok {
+			dst.Set(p)
+			return
+		}
+		p := reflect.New(src.Type().Elem())
+		c.seen[key] = p
+		c.copy(p.Elem(), src.Elem())
+		dst.Set(p.Convert(src.Type()))
+	case reflect.Slice:
+		if src.IsNil() {
+			return
+		}
+		s := reflect.MakeSlice(src.Type(), src.Len(), src.Cap())
+		for i := 0; i < src.Len(); i++ {
+			c.copy(s.Index(i), src.Index(i))
+		}
+		dst.Set(s)
+	case reflect.Map:
+		if src.IsNil() {
+			return
+		}
+		m := reflect.MakeMapWithSize(src.Type(), src.Len())
+		iter := src.MapRange()
+		for iter.Next() {
+			elem := reflect.New(src.Type().Elem()).Elem()
+			c.copy(elem, iter.Value())
+			m.SetMapIndex(iter.Key(), elem)
+		}
+		dst.Set(m)
+	case reflect.Array:
+		for i := 0; i < src.Len(); i++ {
+			c.copy(dst.Index(i), src.Index(i))
+		}
+	case reflect.Struct:
+		dst.Set(src)
+		for i := 0; i < src.NumField(); i++ {
+			if src.Type().Field(i).IsExported() {
+				c.copy(dst.Field(i), src.Field(i))
+			}
+		}
+	case reflect.Interface:
+		if src.IsNil() {
+			return
+		}
+		elem := reflect.New(src.Elem().Type()).Elem()
+		c.copy(elem, src.Elem())
+		dst.Set(elem)
+	default:
+		dst.Set(src)
+	}
+}
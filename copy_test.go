@@ -0,0 +1,107 @@
+package scrub
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCopy(t *testing.T) {
+	type place struct {
+		Name      string `scrub:"true"`
+		Latitude  float64
+		Longitude float64
+	}
+	type person struct {
+		Name     string `scrub:"true"`
+		Age      int
+		Home     *place
+		Places   []place
+		Visits   []*place
+		Labels   map[string]*place
+		Favorite any
+		Nearby   [2]place
+		note     *string
+	}
+	newPerson := func() *person {
+		note := "private"
+		return &person{
+			Name:     "Testy Tester",
+			Age:      26,
+			Home:     &place{Name: "earth", Latitude: 1.0, Longitude: 2.0},
+			Places:   []place{{Name: "mars"}},
+			Visits:   []*place{{Name: "venus"}, nil},
+			Labels:   map[string]*place{"work": {Name: "moon"}},
+			Favorite: place{Name: "pluto"},
+			Nearby:   [2]place{{Name: "io"}, {Name: "europa"}},
+			note:     &note,
+		}
+	}
+
+	t.Run("with a primitive type, returns the value", func(t *testing.T) {
+		assert.Equal(t, 1, Copy(1))
+		assert.Equal(t, "hello", Copy("hello"))
+	})
+
+	t.Run("nil is handled safely", func(t *testing.T) {
+		var nilPtr *person
+		assert.Nil(t, Copy(nilPtr))
+		assert.Nil(t, Copy[any](nil))
+	})
+
+	t.Run("with a struct, returns an equal value", func(t *testing.T) {
+		assert.Equal(t, *newPerson(), Copy(*newPerson()))
+		assert.Equal(t, newPerson(), Copy(newPerson()))
+	})
+
+	t.Run("with a struct, scrubbing the copy leaves the original unchanged", func(t *testing.T) {
+		original := newPerson()
+		copied := Copy(original)
+		TaggedFields(copied)
+		NamedFields(copied, "Latitude")
+		copied.Labels["work"].Name = ""
+		copied.Favorite = nil
+		copied.Nearby[0].Name = ""
+
+		assert.Equal(t, newPerson(), original)
+		assert.Equal(t, "", copied.Name)
+		assert.Equal(t, "", copied.Home.Name)
+		assert.Equal(t, 0.0, copied.Home.Latitude)
+		assert.Equal(t, "", copied.Places[0].Name)
+		assert.Equal(t, "", copied.Visits[0].Name)
+	})
+
+	t.Run("with an interface holding a pointer, copies the pointed-to value", func(t *testing.T) {
+		original := newPerson()
+		copied := Copy[any](original)
+		TaggedFields(copied)
+
+		assert.Equal(t, newPerson(), original)
+		assert.Equal(t, "", copied.(*person).Name)
+	})
+
+	t.Run("with unexported fields, copies them shallowly", func(t *testing.T) {
+		original := newPerson()
+		copied := Copy(original)
+
+		assert.Same(t, original.note, copied.note)
+	})
+
+	t.Run("with shared and cyclic pointers, preserves them", func(t *testing.T) {
+		type node struct {
+			Name string `scrub:"true"`
+			Next *node
+			Also *node
+		}
+		original := &node{Name: "a"}
+		original.Next = &node{Name: "b", Next: original}
+		original.Also = original.Next
+
+		copied := Copy(original)
+
+		assert.NotSame(t, original, copied)
+		assert.Same(t, copied, copied.Next.Next)
+		assert.Same(t, copied.Next, copied.Also)
+		assert.Equal(t, "b", copied.Next.Name)
+	})
+}
@@ -0,0 +1,173 @@
+package scrub
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// Diff compares before, the original value, with after, its scrubbed copy, and returns one line per field
+// that differs, in the form "Path: before -> after", such as
+//
+//	Members[0].Password: <redacted string> -> ""
+//	Members[0].Home.Street: <redacted string> -> "****"
+//
+// Paths are written as in ScrubbedField, with map keys elided. Values from before are elided, unless they're
+// zero, so the diff can be shown in test failures and reviews without leaking them. Values from after are
+// printed, since they're expected to be scrubbed. Structs, pointers, slices, arrays, maps and interfaces are
+// compared element by element, and unexported fields are compared too. Diff returns an empty string if
+// nothing differs.
+//
+// Diff is meant to be used with Copy:
+//
+//	scrubbed := scrub.Copy(user)
+//	scrub.TaggedFields(&scrubbed)
+//	fmt.Println(scrub.Diff(user, scrubbed))
+func Diff(before, after any) string {
+	d := differ{seen: map[pointerPair]bool{}}
+	d.diff("", reflect.ValueOf(before), reflect.ValueOf(after))
+	return strings.Join(d.lines, "\n")
+}
+
+type pointerPair struct {
+	before, after uintptr
+	typ           reflect.Type
+}
+
+type differ struct {
+	lines []string
+	// seen holds the pairs of pointers already compared, so that cycles end.
+	seen map[pointerPair]bool
+}
+
+func (d *differ) diff(path string, before, after reflect.Value) {
+	if !before.IsValid() || !after.IsValid() || before.Type() != after.Type() {
+		if before.IsValid() || after.IsValid() {
+			d.changed(path, before, after)
+		}
+		return
+	}
+
+	switch before.Kind() {
+	case reflect.Ptr:
+		if before.IsNil() || after.IsNil() {
+			if before.IsNil() != after.IsNil() {
+				d.changed(path, before, after)
+			}
+			return
+		}
+		key := pointerPair{before: before.Pointer(), after: after.Pointer(), typ: before.Type()}
+		if d.seen[key] {
+			return
+		}
+		d.seen[key] = true
+		d.diff(path, before.Elem(), after.Elem())
+	case reflect.Interface:
+		if before.IsNil() || after.IsNil() {
+			if before.IsNil() != after.IsNil() {
+				d.changed(path, before, after)
+			}
+			return
+		}
+		d.diff(path, before.Elem(), after.Elem())
+	case reflect.Struct:
+		for i := 0; i < before.NumField(); i++ {
+			name := before.Type().Field(i).Name
+			if path != "" {
+				name = path + "." + name
+			}
+			d.diff(name, before.Field(i), after.Field(i))
+		}
+	case reflect.Slice:
+		if before.IsNil() != after.IsNil() || before.Len() != after.Len() {
+			d.changed(path, before, after)
+			return
+		}
+		d.diffElements(path, before, after)
+	case reflect.Array:
+		d.diffElements(path, before, after)
+	case reflect.Map:
+		if before.IsNil() != after.IsNil() {
+			d.changed(path, before, after)
+			return
+		}
+		d.diffMaps(path, before, after)
+	case reflect.Func:
+		// Funcs aren't comparable, but their code pointers tell whether they were zeroed.
+		if before.Pointer() != after.Pointer() {
+			d.changed(path, before, after)
+		}
+	default:
+		if !before.Equal(after) {
+			d.changed(path, before, after)
+		}
+	}
+}
+
+func (d *differ) diffElements(path string, before, after reflect.Value) {
+	for i := 0; i < before.Len(); i++ {
+		d.diff(path+"["+strconv.Itoa(i)+"]", before.Index(i), after.Index(i))
+	}
+}
+
+// diffMaps compares the entries of two maps, in order of their formatted keys. Since keys can be as
+// sensitive as values, paths show each key's type and position in that order instead of the key itself, as
+// in Labels[<redacted string #1>].
+func (d *differ) diffMaps(path string, before, after reflect.Value) {
+	keys := map[string]reflect.Value{}
+	for _, m := range []reflect.Value{before, after} {
+		for _, k := range m.MapKeys() {
+			s := fmt.Sprint(k)
+			if k.Kind() == reflect.String {
+				s = strconv.Quote(k.String())
+			}
+			keys[s] = k
+		}
+	}
+	for i, s := range sortedKeys(keys) {
+		k := keys[s]
+		elided := "[<redacted " + k.Type().String() + " #" + strconv.Itoa(i) + ">]"
+		d.diff(path+elided, before.MapIndex(k), after.MapIndex(k))
+	}
+}
+
+func (d *differ) changed(path string, before, after reflect.Value) {
+	if path == "" {
+		path = "(root)"
+	}
+	b := describe(before)
+	if before.IsValid() && !before.IsZero() {
+		b = "<redacted " + before.Type().String() + ">"
+	}
+	d.lines = append(d.lines, path+": "+b+" -> "+describe(after))
+}
+
+// describe returns a description of v, which is zero or comes from a scrubbed value, for Diff.
+func describe(v reflect.Value) string {
+	if !v.IsValid() {
+		return "<missing>"
+	}
+	switch v.Kind() {
+	case reflect.Ptr, reflect.Interface:
+		if v.IsNil() {
+			return "nil"
+		}
+		return describe(v.Elem())
+	case reflect.Slice, reflect.Map:
+		if v.IsNil() {
+			return "nil"
+		}
+		return fmt.Sprintf("<%s of length %d>", v.Type(), v.Len())
+	case reflect.Chan, reflect.Func, reflect.UnsafePointer:
+		if v.IsNil() {
+			return "nil"
+		}
+		return "<" + v.Type().String() + ">"
+	case reflect.String:
+		return strconv.Quote(v.String())
+	case reflect.Struct, reflect.Array:
+		return "<" + v.Type().String() + ">"
+	}
+	return fmt.Sprint(v)
+}
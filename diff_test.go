@@ -0,0 +1,134 @@
+package scrub
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDiff(t *testing.T) {
+	type node struct {
+		Secret string
+		Next   *node
+	}
+	type record struct {
+		Name   string
+		Token  *string
+		Age    int
+		Tags   []string
+		Labels map[string]string
+		Extra  any
+		Hook   func()
+		node   *node
+	}
+
+	t.Run("with a scrubbed copy, lists the changed fields without their original values", func(t *testing.T) {
+		type address struct {
+			Street string
+			City   string
+		}
+		type user struct {
+			Name     string
+			Password string
+			Home     address
+			Work     *address
+			Friends  []*user
+		}
+		type team struct {
+			Name    string
+			Members []user
+		}
+		newTeam := func() *team {
+			return &team{
+				Name: "Testers",
+				Members: []user{{
+					Name:     "Testy Tester",
+					Password: "hunter2",
+					Home:     address{Street: "742 Evergreen Terrace", City: "Springfield"},
+					Work:     &address{Street: "100 Industrial Way", City: "Springfield"},
+					Friends:  []*user{{Name: "Friendly Tester", Password: "hunter3"}},
+				}},
+			}
+		}
+		original := newTeam()
+		scrubbed := Copy(original)
+		p := Policy{Rules: []Rule{{Name: "Password"}, {Path: "**.Home.Street", Action: Mask}, {Name: "Work"}}}
+		require.NoError(t, p.Apply(scrubbed))
+
+		diff := Diff(original, scrubbed)
+
+		assert.Equal(t, `Members[0].Password: <redacted string> -> ""
+Members[0].Home.Street: <redacted string> -> "****"
+Members[0].Work: <redacted *scrub.address> -> nil
+Members[0].Friends[0].Password: <redacted string> -> ""
+Members[0].Friends[0].Home.Street: "" -> "****"`, diff)
+		assert.NotContains(t, diff, "hunter2")
+		assert.NotContains(t, diff, "Evergreen")
+		assert.Equal(t, "", Diff(newTeam(), newTeam()))
+	})
+
+	t.Run("with pointers, slices, maps, interfaces and unexported fields, compares their elements", func(t *testing.T) {
+		token := "tok_123"
+		before := record{
+			Token:  &token,
+			Age:    42,
+			Tags:   []string{"a", "b"},
+			Labels: map[string]string{"env": "prod", "team": "core"},
+			Extra:  node{Secret: "s3cr3t"},
+			Hook:   func() {},
+			node:   &node{Secret: "s3cr3t"},
+		}
+		hashed := Hash.Replace(token)
+		after := record{
+			Token:  &hashed,
+			Tags:   []string{"a", "****"},
+			Labels: map[string]string{"env": "prod", "owner": "****"},
+			Extra:  node{},
+			node:   &node{},
+		}
+
+		assert.Equal(t, `Token: <redacted string> -> "`+hashed+`"
+Age: <redacted int> -> 0
+Tags[1]: <redacted string> -> "****"
+Labels[<redacted string #1>]: <missing> -> "****"
+Labels[<redacted string #2>]: <redacted string> -> <missing>
+Extra.Secret: <redacted string> -> ""
+Hook: <redacted func()> -> nil
+node.Secret: <redacted string> -> ""`, Diff(before, after))
+	})
+
+	t.Run("with map keys, elides them", func(t *testing.T) {
+		type team struct {
+			Passwords map[string]string
+		}
+		before := team{Passwords: map[string]string{"alice@example.com": "hunter2", "bob@example.com": "hunter3"}}
+		after := team{Passwords: map[string]string{"alice@example.com": "", "bob@example.com": "hunter3"}}
+
+		diff := Diff(before, after)
+
+		assert.Equal(t, `Passwords[<redacted string #0>]: <redacted string> -> ""`, diff)
+		assert.NotContains(t, diff, "alice")
+	})
+
+	t.Run("with slices of different lengths, reports the whole slice", func(t *testing.T) {
+		assert.Equal(t, "Tags: <redacted []string> -> <[]string of length 1>",
+			Diff(record{Tags: []string{"a", "b"}}, record{Tags: []string{"a"}}))
+		assert.Equal(t, "Tags: nil -> <[]string of length 0>", Diff(record{}, record{Tags: []string{}}))
+	})
+
+	t.Run("with a cycle, terminates", func(t *testing.T) {
+		before := &node{Secret: "s3cr3t"}
+		before.Next = before
+		after := Copy(before)
+		after.Secret = ""
+
+		assert.Equal(t, `Secret: <redacted string> -> ""`, Diff(before, after))
+	})
+
+	t.Run("with values of different types, reports the root", func(t *testing.T) {
+		assert.Equal(t, `(root): <redacted string> -> 0`, Diff("s3cr3t", 0))
+		assert.Equal(t, `(root): <missing> -> ""`, Diff(nil, ""))
+		assert.Equal(t, "", Diff(nil, nil))
+	})
+}
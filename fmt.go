@@ -0,0 +1,67 @@
+package scrub
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+)
+
+// Formatter prints a scrubbed copy of the value it wraps with the fmt package. See Fmt.
+type Formatter struct {
+	v     any
+	names []string
+}
+
+// Fmt wraps v so that printing it with the fmt package, using any verb including %v, %+v and %#v, prints a
+// scrubbed deep copy of v instead. Fields annotated with a `scrub:"true"` struct tag are scrubbed, as are
+// fields with any of the given names. v itself is left unchanged, so it's safe to use in log and error
+// messages:
+//
+//	fmt.Printf("signing in %+v\n", scrub.Fmt(user, "Password"))
+//
+// Structs, pointers to structs and slices of either are scrubbed, including those with cycles; other values
+// are printed as they are.
+func Fmt(v any, names ...string) Formatter {
+	return Formatter{v: v, names: names}
+}
+
+// Format implements fmt.Formatter by printing the scrubbed copy with the same verb and flags.
+func (f Formatter) Format(s fmt.State, verb rune) {
+	fmt.Fprintf(s, fmt.FormatString(s, verb), f.scrubbed())
+}
+
+// String implements fmt.Stringer by printing the scrubbed copy with %v.
+func (f Formatter) String() string {
+	return fmt.Sprint(f.scrubbed())
+}
+
+// GoString implements fmt.GoStringer by printing the scrubbed copy with %#v.
+func (f Formatter) GoString() string {
+	return fmt.Sprintf("%#v", f.scrubbed())
+}
+
+// scrubbed returns a scrubbed deep copy of the wrapped value.
+func (f Formatter) scrubbed() any {
+	if f.v == nil {
+		return nil
+	}
+	src := reflect.ValueOf(f.v)
+	dst := reflect.New(src.Type()).Elem()
+	c := copier{seen: map[pointer]reflect.Value{}}
+	c.copy(dst, src)
+
+	// The copy keeps any cycles in v, so they're detected to make sure the walk ends.
+	ctx := context.Background()
+	w := walker{tagged: true, names: f.names, seen: map[pointer]bool{}}
+	switch dst.Kind() {
+	case reflect.Struct:
+		_ = w.walkStruct(ctx, dst)
+	case reflect.Ptr:
+		if !dst.IsNil() && dst.Elem().Kind() == reflect.Struct && w.enter(dst) {
+			_ = w.walkStruct(ctx, dst.Elem())
+		}
+	case reflect.Slice:
+		_ = w.walkSlice(ctx, dst)
+	}
+	return dst.Interface()
+}
@@ -0,0 +1,105 @@
+package scrub
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFmt(t *testing.T) {
+	t.Run("with %v, prints a scrubbed copy", func(t *testing.T) {
+		type user struct {
+			Name     string
+			Email    string `scrub:"true"`
+			Password string
+		}
+		u := user{Name: "Testy Tester", Email: "testy@example.com", Password: "hunter2"}
+		assert.Equal(t, "{Testy Tester  hunter2}", fmt.Sprintf("%v", Fmt(u)))
+		assert.Equal(t, "testy@example.com", u.Email)
+	})
+
+	t.Run("with %+v and names, also scrubs the named fields", func(t *testing.T) {
+		type user struct {
+			Name     string
+			Email    string `scrub:"true"`
+			Password string
+		}
+		u := &user{Name: "Testy Tester", Email: "testy@example.com", Password: "hunter2"}
+		assert.Equal(t, "&{Name:Testy Tester Email: Password:}", fmt.Sprintf("%+v", Fmt(u, "Password")))
+		assert.Equal(t, "hunter2", u.Password)
+	})
+
+	t.Run("with %#v, prints a scrubbed copy with Go syntax", func(t *testing.T) {
+		type address struct {
+			City string
+		}
+		type user struct {
+			Name  string
+			Email string `scrub:"true"`
+			Home  *address
+		}
+		u := &user{Name: "Testy Tester", Email: "testy@example.com"}
+		assert.Equal(t, `&scrub.user{Name:"Testy Tester", Email:"", Home:(*scrub.address)(nil)}`, fmt.Sprintf("%#v", Fmt(u)))
+		assert.Equal(t, "testy@example.com", u.Email)
+	})
+
+	t.Run("with nested structs, scrubs them without changing the original", func(t *testing.T) {
+		type address struct {
+			City   string
+			Street string `scrub:"true"`
+		}
+		type user struct {
+			Name string
+			Home *address
+		}
+		u := &user{Name: "Testy Tester", Home: &address{City: "Springfield", Street: "742 Evergreen Terrace"}}
+		assert.Equal(t, "{Springfield }", fmt.Sprintf("%v", Fmt(*u.Home)))
+		assert.Equal(t, "[{Springfield }]", fmt.Sprintf("%v", Fmt([]address{*u.Home})))
+
+		scrubbed := Fmt(u).scrubbed().(*user)
+		assert.Equal(t, "", scrubbed.Home.Street)
+		assert.Equal(t, "742 Evergreen Terrace", u.Home.Street)
+	})
+
+	t.Run("with String and GoString, prints a scrubbed copy", func(t *testing.T) {
+		type address struct {
+			City   string
+			Street string `scrub:"true"`
+		}
+		a := address{City: "Springfield", Street: "742 Evergreen Terrace"}
+		assert.Equal(t, "{Springfield }", Fmt(a).String())
+		assert.Equal(t, `scrub.address{City:"Springfield", Street:""}`, Fmt(a).GoString())
+	})
+
+	t.Run("with flags and width, passes them through", func(t *testing.T) {
+		assert.Equal(t, "  42", fmt.Sprintf("%4d", Fmt(42)))
+		assert.Equal(t, `"hi"`, fmt.Sprintf("%q", Fmt("hi")))
+	})
+
+	t.Run("with a cycle, prints a scrubbed copy", func(t *testing.T) {
+		type node struct {
+			Name   string `scrub:"true"`
+			Parent *node
+			Nodes  []*node
+		}
+		n := &node{Name: "root"}
+		n.Parent = n
+		n.Nodes = []*node{n, {Name: "leaf", Parent: n}}
+
+		printed := fmt.Sprintf("%+v", Fmt(n))
+
+		assert.Contains(t, printed, "&{Name: Parent:0x")
+		assert.NotContains(t, printed, "root")
+		assert.Equal(t, "root", n.Name)
+	})
+
+	t.Run("nil is handled safely", func(t *testing.T) {
+		type user struct {
+			Name string
+		}
+		var u *user
+		assert.Equal(t, "<nil>", fmt.Sprintf("%v", Fmt(u)))
+		assert.Equal(t, "<nil>", fmt.Sprintf("%v", Fmt(nil)))
+	})
+}
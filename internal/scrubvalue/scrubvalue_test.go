@@ -0,0 +1,86 @@
+package scrubvalue
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCopy(t *testing.T) {
+	type user struct {
+		Name     string `json:"name"`
+		Email    string `scrub:"true"`
+		Password string `json:"-"`
+		note     string
+	}
+
+	newUser := func() user {
+		return user{Name: "Testy Tester", Email: "testy@example.com", Password: "hunter2", note: "private"}
+	}
+
+	t.Run("with a struct, returns a scrubbed copy", func(t *testing.T) {
+		u := newUser()
+		assert.Equal(t, user{Name: "Testy Tester", Password: "", note: "private"}, Copy(u, []string{"Password"}))
+		assert.Equal(t, newUser(), u)
+	})
+
+	t.Run("with a pointer to a struct, returns a pointer to a scrubbed copy", func(t *testing.T) {
+		u := newUser()
+		assert.Equal(t, &user{Name: "Testy Tester", Password: "hunter2", note: "private"}, Copy(&u, nil))
+		assert.Equal(t, newUser(), u)
+	})
+
+	t.Run("with a slice of pointers to structs, returns scrubbed copies", func(t *testing.T) {
+		u := newUser()
+		expected := []*user{{Name: "Testy Tester", Password: "hunter2", note: "private"}, nil}
+		assert.Equal(t, expected, Copy([]*user{&u, nil}, nil))
+		assert.Equal(t, newUser(), u)
+	})
+
+	t.Run("with a cycle, returns a scrubbed copy", func(t *testing.T) {
+		type node struct {
+			Name   string `scrub:"true"`
+			Parent *node
+		}
+		n := &node{Name: "root"}
+		n.Parent = n
+
+		copied := Copy(n, nil).(*node)
+		assert.Equal(t, "", copied.Name)
+		assert.Same(t, copied, copied.Parent)
+		assert.Equal(t, "root", n.Name)
+	})
+
+	t.Run("with other values, returns them unchanged", func(t *testing.T) {
+		assert.Nil(t, Copy(nil, nil))
+		assert.Equal(t, 1, Copy(1, nil))
+		assert.Equal(t, []string{"a"}, Copy([]string{"a"}, nil))
+	})
+}
+
+func TestFields(t *testing.T) {
+	type user struct {
+		Name     string `json:"name"`
+		Email    string `scrub:"true"`
+		Password string `json:"-"`
+		note     string
+	}
+
+	t.Run("with a struct, visits the exported fields using their json names", func(t *testing.T) {
+		var keys []string
+		var values []any
+		Fields(&user{Name: "Testy Tester", Email: "testy@example.com"}, func(key string, value any) {
+			keys = append(keys, key)
+			values = append(values, value)
+		})
+
+		assert.Equal(t, []string{"name", "Email"}, keys)
+		assert.Equal(t, []any{"Testy Tester", "testy@example.com"}, values)
+	})
+
+	t.Run("with a nil pointer or a non-struct, visits nothing", func(t *testing.T) {
+		var u *user
+		Fields(u, func(string, any) { t.Fail() })
+		Fields(1, func(string, any) { t.Fail() })
+	})
+}
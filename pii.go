@@ -0,0 +1,224 @@
+package scrub
+
+import (
+	"fmt"
+	"math/big"
+	"net/netip"
+	"regexp"
+	"strings"
+)
+
+// PII is a kind of personal information that can be found in free-form strings, such as notes and messages,
+// by its content rather than by the name of the field holding it.
+type PII string
+
+const (
+	// Email finds email addresses.
+	Email PII = "email"
+	// Phone finds phone numbers written with separators, such as "(555) 123-4567", "+1 555.123.4567" or
+	// "+44 20 7946 0958", and numbers in E.164 format, such as "+15551234567".
+	Phone PII = "phone"
+	// CreditCard finds payment card numbers of 13 to 19 digits, optionally grouped with spaces or dashes,
+	// that pass the Luhn check.
+	CreditCard PII = "credit_card"
+	// SSN finds US Social Security numbers written as "123-45-6789", leaving out numbers that are never
+	// issued.
+	SSN PII = "ssn"
+	// IBAN finds international bank account numbers, optionally grouped with spaces, that pass the mod-97
+	// check.
+	IBAN PII = "iban"
+	// IPv4 finds IPv4 addresses.
+	IPv4 PII = "ipv4"
+	// IPv6 finds IPv6 addresses.
+	IPv6 PII = "ipv6"
+)
+
+// allPII lists every kind of PII, in the order they're looked for.
+var allPII = []PII{Email, Phone, CreditCard, SSN, IBAN, IPv4, IPv6}
+
+// ParsePII returns the kind of PII with the given name, such as "email".
+func ParsePII(name string) (PII, error) {
+	if p, ok := lookupPII(name); ok {
+		return p, nil
+	}
+	return "", fmt.Errorf("scrub: unknown kind of PII %q", name)
+}
+
+// lookupPII is like ParsePII, but doesn't build an error, which would make name escape to the heap while
+// scrubbing.
+func lookupPII(name string) (PII, bool) {
+	for _, p := range allPII {
+		if string(p) == name {
+			return p, true
+		}
+	}
+	return "", false
+}
+
+var (
+	emailPattern      = regexp.MustCompile(`[A-Za-z0-9._%+-]+@[A-Za-z0-9-]+(?:\.[A-Za-z0-9-]+)*\.[A-Za-z]{2,}`)
+	phonePattern      = regexp.MustCompile(`(?:\+\d{1,3}[ .-]?)?(?:\(\d{2,4}\)[ .-]?|\b\d{2,4}[ .-])\d{3,4}[ .-]\d{4}\b|\+\d{10,15}\b`)
+	creditCardPattern = regexp.MustCompile(`\b\d(?:[ -]?\d){12,18}\b`)
+	ssnPattern        = regexp.MustCompile(`\b(\d{3})-(\d{2})-(\d{4})\b`)
+	ibanPattern       = regexp.MustCompile(`\b[A-Z]{2}\d{2}(?: ?[A-Z0-9]{4}){2,7}(?: ?[A-Z0-9]{1,3})?\b`)
+	// Extra dotted parts are matched, so that version numbers such as 1.2.3.4.5 are rejected as a whole.
+	ipv4Pattern = regexp.MustCompile(`\b\d{1,3}(?:\.\d{1,3}){3}(?:\.\d+)*\b`)
+	ipv6Pattern = regexp.MustCompile(`[0-9A-Fa-f]{0,4}(?::[0-9A-Fa-f]{0,4}){2,7}`)
+)
+
+// Find returns the start and end offsets in s of each match of the kind of PII, like
+// regexp.Regexp.FindAllStringIndex. It returns nil for an unknown kind.
+func (p PII) Find(s string) [][]int {
+	switch p {
+	case Email:
+		return emailPattern.FindAllStringIndex(s, -1)
+	case Phone:
+		return phonePattern.FindAllStringIndex(s, -1)
+	case CreditCard:
+		return findValid(creditCardPattern, s, func(m string) bool {
+			return luhn(digits(m))
+		})
+	case SSN:
+		return findValid(ssnPattern, s, func(m string) bool {
+			area, group, serial := m[0:3], m[4:6], m[7:11]
+			return area != "000" && area != "666" && area[0] != '9' && group != "00" && serial != "0000"
+		})
+	case IBAN:
+		return findValid(ibanPattern, s, func(m string) bool {
+			return validIBAN(strings.ReplaceAll(m, " ", ""))
+		})
+	case IPv4:
+		return findValid(ipv4Pattern, s, func(m string) bool {
+			addr, err := netip.ParseAddr(m)
+			return err == nil && addr.Is4()
+		})
+	case IPv6:
+		return findIPv6(s)
+	default:
+		return nil
+	}
+}
+
+// findValid returns the matches of re in s for which valid returns true.
+func findValid(re *regexp.Regexp, s string, valid func(string) bool) [][]int {
+	var found [][]int
+	for _, loc := range re.FindAllStringIndex(s, -1) {
+		if valid(s[loc[0]:loc[1]]) {
+			found = append(found, loc)
+		}
+	}
+	return found
+}
+
+// findIPv6 returns the IPv6 addresses in s. Matches next to letters, digits or colons are left out, since
+// they're parts of something else, such as the "d::" of "std::vector".
+func findIPv6(s string) [][]int {
+	var found [][]int
+	for _, loc := range ipv6Pattern.FindAllStringIndex(s, -1) {
+		if loc[0] > 0 && isIPv6Neighbor(s[loc[0]-1]) || loc[1] < len(s) && isIPv6Neighbor(s[loc[1]]) {
+			continue
+		}
+		if addr, err := netip.ParseAddr(s[loc[0]:loc[1]]); err == nil && addr.Is6() {
+			found = append(found, loc)
+		}
+	}
+	return found
+}
+
+// isIPv6Neighbor reports whether c can't be next to an IPv6 address: a letter, a digit or a colon.
+func isIPv6Neighbor(c byte) bool {
+	return 'a' <= c && c <= 'z' || 'A' <= c && c <= 'Z' || '0' <= c && c <= '9' || c == ':'
+}
+
+// digits returns the decimal digits in s.
+func digits(s string) string {
+	return strings.Map(func(r rune) rune {
+		if '0' <= r && r <= '9' {
+			return r
+		}
+		return -1
+	}, s)
+}
+
+// luhn reports whether the decimal digits in s pass the Luhn check.
+func luhn(s string) bool {
+	sum := 0
+	for i := 0; i < len(s); i++ {
+		d := int(s[len(s)-1-i] - '0')
+		if i%2 == 1 {
+			d *= 2
+			if d > 9 {
+				d -= 9
+			}
+		}
+		sum += d
+	}
+	return sum%10 == 0
+}
+
+// validIBAN reports whether s, an IBAN without spaces, has a valid length and passes the mod-97 check.
+func validIBAN(s string) bool {
+	if len(s) < 15 || len(s) > 34 {
+		return false
+	}
+	var b strings.Builder
+	for _, c := range s[4:] + s[:4] {
+		if 'A' <= c && c <= 'Z' {
+			fmt.Fprintf(&b, "%d", c-'A'+10)
+		} else {
+			b.WriteRune(c)
+		}
+	}
+	n, ok := new(big.Int).SetString(b.String(), 10)
+	return ok && new(big.Int).Mod(n, big.NewInt(97)).Int64() == 1
+}
+
+// RedactOnly returns an action like Redact that only uses the given built-in detectors, such as
+// "redact:email+phone". Any kind of PII or Secret, and Entropy with its defaults, can be used. With no
+// detectors, it returns Redact.
+func RedactOnly(detectors ...Detector) Action {
+	if len(detectors) == 0 {
+		return Redact
+	}
+	names := make([]string, len(detectors))
+	for i, d := range detectors {
+		names[i] = detectorName(d)
+	}
+	return Redact + ":" + Action(strings.Join(names, "+"))
+}
+
+// redactDetectors returns the detectors that a uses, if it's Redact or was returned by RedactOnly.
+func (a Action) redactDetectors() ([]Detector, bool) {
+	if a == Redact {
+		return piiDetectors, true
+	}
+	list, ok := strings.CutPrefix(string(a), string(Redact)+":")
+	if !ok {
+		return nil, false
+	}
+	// Cut, unlike Split, doesn't make a escape to the heap while scrubbing.
+	var detectors []Detector
+	for more := true; more; {
+		var name string
+		name, list, more = strings.Cut(list, "+")
+		d, ok := lookupDetector(name)
+		if !ok {
+			return nil, false
+		}
+		detectors = append(detectors, d)
+	}
+	return detectors, true
+}
+
+// WithPII runs detectors for the given kinds of PII, or for all of them if none are given, as WithDetectors
+// does.
+func WithPII(kinds ...PII) Option {
+	if len(kinds) == 0 {
+		return WithDetectors(piiDetectors...)
+	}
+	detectors := make([]Detector, len(kinds))
+	for i, k := range kinds {
+		detectors[i] = k
+	}
+	return WithDetectors(detectors...)
+}
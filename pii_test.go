@@ -0,0 +1,135 @@
+package scrub
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// found returns the substrings of s that p finds.
+func found(p PII, s string) []string {
+	var matches []string
+	for _, loc := range p.Find(s) {
+		matches = append(matches, s[loc[0]:loc[1]])
+	}
+	return matches
+}
+
+func TestPIIFind(t *testing.T) {
+	for _, c := range []struct {
+		kind     PII
+		text     string
+		expected []string
+	}{
+		{Email, "write to testy.tester+scrub@mail.example.co.uk or a@b.io", []string{"testy.tester+scrub@mail.example.co.uk", "a@b.io"}},
+		{Email, "not @ an address, nor testy@localhost", nil},
+		{Phone, "call (555) 123-4567, +1 555.123.4567, +44 20 7946 0958 or +15551234567", []string{"(555) 123-4567", "+1 555.123.4567", "+44 20 7946 0958", "+15551234567"}},
+		{Phone, "order 12345678 shipped in 2024", nil},
+		{CreditCard, "cards 4111 1111 1111 1111, 5500-0000-0000-0004 and 378282246310005", []string{"4111 1111 1111 1111", "5500-0000-0000-0004", "378282246310005"}},
+		{CreditCard, "not a card: 4111 1111 1111 1112", nil},
+		{SSN, "SSN 123-45-6789", []string{"123-45-6789"}},
+		{SSN, "never issued: 000-12-3456, 666-12-3456, 900-12-3456, 123-00-4567, 123-45-0000", nil},
+		{IBAN, "pay GB82 WEST 1234 5698 7654 32 or DE89370400440532013000", []string{"GB82 WEST 1234 5698 7654 32", "DE89370400440532013000"}},
+		{IBAN, "bad checksum: GB82 WEST 1234 5698 7654 33", nil},
+		{IPv4, "from 192.168.0.1 via 10.0.0.254", []string{"192.168.0.1", "10.0.0.254"}},
+		{IPv4, "version 1.2.3.4.5 or 256.1.1.1", nil},
+		{IPv6, "from 2001:db8::8a2e:370:7334 and ::1", []string{"2001:db8::8a2e:370:7334", "::1"}},
+		{IPv6, "at 12:30:45 from aa:bb:cc:dd:ee:ff", nil},
+		{IPv6, "use std::vector here, call Foo::Bar or a::b::c", nil},
+	} {
+		assert.Equal(t, c.expected, found(c.kind, c.text), "%s in %q", c.kind, c.text)
+	}
+}
+
+func TestParsePII(t *testing.T) {
+	t.Run("with a known kind, returns it", func(t *testing.T) {
+		p, err := ParsePII("credit_card")
+		require.NoError(t, err)
+		assert.Equal(t, CreditCard, p)
+	})
+
+	t.Run("with an unknown kind, returns an error", func(t *testing.T) {
+		_, err := ParsePII("dna")
+		assert.EqualError(t, err, `scrub: unknown kind of PII "dna"`)
+	})
+}
+
+func TestRedactOnly(t *testing.T) {
+	t.Run("with kinds, names them in the action", func(t *testing.T) {
+		assert.Equal(t, Action("redact:email+ipv4"), RedactOnly(Email, IPv4))
+	})
+
+	t.Run("without kinds, returns Redact", func(t *testing.T) {
+		assert.Equal(t, Redact, RedactOnly())
+	})
+
+	t.Run("with C++ or Rust paths, leaves them alone", func(t *testing.T) {
+		assert.Equal(t, "use std::vector here", Redact.Replace("use std::vector here"))
+		assert.Equal(t, "call Foo::Bar", Redact.Replace("call Foo::Bar"))
+	})
+
+	t.Run("with overlapping matches, replaces them together", func(t *testing.T) {
+		assert.Equal(t, "card ****.", Redact.Replace("card 4111 1111 1111 1111."))
+	})
+}
+
+func TestWithPII(t *testing.T) {
+	type issue struct {
+		Subject  string
+		Notes    string
+		Reporter *string
+		Secret   string `scrub:"true"`
+		Count    int
+		Replies  []issue
+	}
+
+	t.Run("with a scrub, redacts PII in every string it reaches", func(t *testing.T) {
+		reporter := "testy@example.com"
+		ticket := &issue{
+			Subject:  "Refund",
+			Notes:    "Card 4111 1111 1111 1111, call (555) 123-4567",
+			Reporter: &reporter,
+			Secret:   "hunter2",
+			Replies:  []issue{{Notes: "Sent to 192.168.0.1"}},
+		}
+
+		require.NoError(t, TaggedFieldsContext(context.Background(), ticket, WithPII()))
+
+		assert.Equal(t, "Refund", ticket.Subject)
+		assert.Equal(t, "Card ****, call ****", ticket.Notes)
+		assert.Equal(t, "****", *ticket.Reporter)
+		assert.Equal(t, "testy@example.com", reporter, "shared strings are left alone")
+		assert.Equal(t, "", ticket.Secret)
+		assert.Equal(t, "Sent to ****", ticket.Replies[0].Notes)
+	})
+
+	t.Run("with kinds, only redacts those kinds", func(t *testing.T) {
+		ticket := &issue{Notes: "testy@example.com from 192.168.0.1"}
+
+		require.NoError(t, NamedFieldsContext(context.Background(), ticket, nil, WithPII(IPv4)))
+
+		assert.Equal(t, "testy@example.com from ****", ticket.Notes)
+	})
+
+	t.Run("with a report or a dry run, lists the redacted fields", func(t *testing.T) {
+		ticket := &issue{Subject: "Refund", Notes: "call (555) 123-4567"}
+		var report Report
+
+		require.NoError(t, NamedFieldsContext(context.Background(), ticket, nil, WithPII(), WithDryRun(&report)))
+
+		assert.Equal(t, "call (555) 123-4567", ticket.Notes)
+		assert.Equal(t, []ScrubbedField{{Path: "Notes", Type: "string", Action: Redact, Rule: "detect=phone"}}, report.Fields)
+	})
+
+	t.Run("with a policy, redacts the fields its rules select", func(t *testing.T) {
+		p := Policy{Rules: []Rule{{Name: "Notes", Action: RedactOnly(Email)}}}
+		ticket := &issue{Subject: "testy@example.com", Notes: "from testy@example.com"}
+
+		require.NoError(t, p.Apply(ticket))
+
+		assert.Equal(t, "testy@example.com", ticket.Subject)
+		assert.Equal(t, "from ****", ticket.Notes)
+	})
+}
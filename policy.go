@@ -0,0 +1,294 @@
+package scrub
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"reflect"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Policy lists the fields to scrub and what to do with them, so that the list can be kept in a file that
+// changes without code changes. Types maps the names of types registered with RegisterType to rules for
+// values of those types, whose paths start at the type, and Rules holds rules for values of any type,
+// whose paths start at the value passed to Apply. Names, globs and paths refer to Go field names.
+//
+// In JSON or YAML, a policy looks like this:
+//
+//	types:
+//	  User:
+//	    - name: Password
+//	    - path: Address.Street
+//	      action: mask
+//	rules:
+//	  - glob: "*Token"
+//	    action: hash
+type Policy struct {
+	Types map[string][]Rule `json:"types,omitempty" yaml:"types,omitempty"`
+	Rules []Rule            `json:"rules,omitempty" yaml:"rules,omitempty"`
+}
+
+var (
+	registryMu sync.RWMutex
+	registry   = map[string]reflect.Type{}
+)
+
+// RegisterType makes the struct type of v, which may also be a pointer to a struct, available to policies
+// under the given name. It panics if v isn't a struct or a pointer to one, or if the name is already
+// registered for a different type. It's meant to be called from init functions.
+func RegisterType(name string, v any) {
+	t := reflect.TypeOf(v)
+	if t != nil && t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if t == nil || t.Kind() != reflect.Struct {
+		panic(fmt.Sprintf("scrub: can't register %T as %q: not a struct", v, name))
+	}
+
+	registryMu.Lock()
+	defer registryMu.Unlock()
+	if registered, ok := registry[name]; ok && registered != t {
+		panic(fmt.Sprintf("scrub: can't register %s as %q: already registered for %s", t, name, registered))
+	}
+	registry[name] = t
+}
+
+func registeredType(name string) (reflect.Type, bool) {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+	t, ok := registry[name]
+	return t, ok
+}
+
+// ParsePolicy parses and validates a policy in JSON or YAML. Unknown keys are an error.
+func ParsePolicy(data []byte) (*Policy, error) {
+	var p Policy
+	var err error
+	if trimmed := bytes.TrimSpace(data); len(trimmed) > 0 && trimmed[0] == '{' {
+		dec := json.NewDecoder(bytes.NewReader(data))
+		dec.DisallowUnknownFields()
+		err = dec.Decode(&p)
+	} else {
+		dec := yaml.NewDecoder(bytes.NewReader(data))
+		dec.KnownFields(true)
+		if err = dec.Decode(&p); err == io.EOF {
+			err = nil
+		}
+	}
+	if err != nil {
+		return nil, fmt.Errorf("scrub: parsing policy: %w", err)
+	}
+	if err := p.Validate(); err != nil {
+		return nil, err
+	}
+	return &p, nil
+}
+
+// Validate returns an error if any rule is invalid, if a type isn't registered, or if a rule for a type
+// doesn't match any field that Apply could reach in that type.
+func (p *Policy) Validate() error {
+	for i, r := range p.Rules {
+		if err := r.validate(); err != nil {
+			return fmt.Errorf("scrub: policy rule %d: %w", i+1, err)
+		}
+	}
+
+	for _, name := range sortedKeys(p.Types) {
+		t, ok := registeredType(name)
+		if !ok {
+			return fmt.Errorf("scrub: policy names unregistered type %q", name)
+		}
+		for i, r := range p.Types[name] {
+			if err := r.validate(); err != nil {
+				return fmt.Errorf("scrub: policy rule %d for type %s: %w", i+1, name, err)
+			}
+			if !matchesField(r, t) {
+				return fmt.Errorf("scrub: policy rule %d for type %s matches no fields of %s", i+1, name, t)
+			}
+		}
+	}
+	return nil
+}
+
+// Apply scrubs src, which should be a pointer to a struct, according to the policy. Omitted fields are
+// zeroed, since they can't be removed from a struct.
+func (p *Policy) Apply(src any) error {
+	return p.ApplyContext(context.Background(), src)
+}
+
+// ApplyContext is like Apply, but accepts the same options as TaggedFieldsContext and stops early in the
+// same cases.
+func (p *Policy) ApplyContext(ctx context.Context, src any, opts ...Option) error {
+	rules := &policyRules{types: make(map[reflect.Type]typeRules, len(p.Types)), rules: p.Rules}
+	for _, name := range sortedKeys(p.Types) {
+		t, ok := registeredType(name)
+		if !ok {
+			return fmt.Errorf("scrub: policy names unregistered type %q", name)
+		}
+		tr, ok := rules.types[t]
+		if !ok {
+			// If a type is registered under more than one name, it's reported under the first.
+			tr.name = name
+		}
+		tr.rules = append(tr.rules, p.Types[name]...)
+		rules.types[t] = tr
+	}
+	return scrub(ctx, src, walker{policy: &policyState{rules: rules}}, opts)
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// policyRules holds a policy's rules with its types resolved.
+type policyRules struct {
+	types map[reflect.Type]typeRules
+	rules []Rule
+}
+
+// typeRules holds the rules for a registered type, and the name it's registered under.
+type typeRules struct {
+	name  string
+	rules []Rule
+}
+
+// policyState tracks where a walker applying a policy is. path holds the names of the fields leading to the
+// current one, and scopes holds the rules for the registered types being walked.
+type policyState struct {
+	rules  *policyRules
+	path   []string
+	scopes []ruleScope
+}
+
+// enter starts a scope for the rules of t, if it's a registered type with rules, and reports whether it
+// did.
+func (p *policyState) enter(t reflect.Type) bool {
+	tr, ok := p.rules.types[t]
+	if ok {
+		p.scopes = append(p.scopes, ruleScope{typeRules: tr, base: len(p.path)})
+	}
+	return ok
+}
+
+// match returns the rule that selects the field with the given name at the end of p.path, and the name of
+// the type the rule is for, if any. The rules of the innermost registered type take precedence, and the
+// policy's rules for values of any type come last.
+func (p *policyState) match(name string) (Rule, string, bool) {
+	for i := len(p.scopes) - 1; i >= 0; i-- {
+		scope := p.scopes[i]
+		for _, r := range scope.rules {
+			if r.Matches(name, p.path[scope.base:]) {
+				return r, scope.name, true
+			}
+		}
+	}
+	for _, r := range p.rules.rules {
+		if r.Matches(name, p.path) {
+			return r, "", true
+		}
+	}
+	return Rule{}, "", false
+}
+
+func (p *policyState) leave() {
+	p.scopes = p.scopes[:len(p.scopes)-1]
+}
+
+// fork returns a copy of p for use by a worker goroutine.
+func (p *policyState) fork() *policyState {
+	if p == nil {
+		return nil
+	}
+	return &policyState{
+		rules:  p.rules,
+		path:   append([]string(nil), p.path...),
+		scopes: append([]ruleScope(nil), p.scopes...),
+	}
+}
+
+type ruleScope struct {
+	typeRules
+	// base is the length of the walker's path at the struct the rules apply to.
+	base int
+}
+
+// matchesField reports whether r matches any field that a walker could visit in a value of the struct type
+// t, through nested structs, pointers to structs and slices of either. It follows the type graph and the
+// elements of r's path together, so that it terminates for recursive types.
+func matchesField(r Rule, t reflect.Type) bool {
+	pattern := []string{"**"}
+	if r.Path != "" {
+		pattern = strings.Split(r.Path, ".")
+	}
+
+	// closure returns the positions in pattern that are equivalent to i, since "**" can match nothing.
+	closure := func(i int) []int {
+		positions := []int{i}
+		for i < len(pattern) && pattern[i] == "**" {
+			i++
+			positions = append(positions, i)
+		}
+		return positions
+	}
+
+	type state struct {
+		t reflect.Type
+		i int
+	}
+	seen := map[state]bool{}
+	var search func(t reflect.Type, i int) bool
+	search = func(t reflect.Type, i int) bool {
+		if seen[state{t, i}] {
+			return false
+		}
+		seen[state{t, i}] = true
+
+		for f := 0; f < t.NumField(); f++ {
+			field := t.Field(f)
+			if !field.IsExported() {
+				continue
+			}
+			for _, k := range closure(i) {
+				if k == len(pattern) {
+					continue
+				}
+				next := k + 1
+				switch pattern[k] {
+				case "**":
+					next = k
+				case "*", field.Name:
+				default:
+					continue
+				}
+
+				if slices.Contains(closure(next), len(pattern)) && r.matchesName(field.Name) {
+					return true
+				}
+				ft := field.Type
+				if ft.Kind() == reflect.Slice {
+					ft = ft.Elem()
+				}
+				if ft.Kind() == reflect.Ptr {
+					ft = ft.Elem()
+				}
+				if ft.Kind() == reflect.Struct && search(ft, next) {
+					return true
+				}
+			}
+		}
+		return false
+	}
+	return search(t, 0)
+}
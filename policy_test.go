@@ -0,0 +1,226 @@
+package scrub
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type policyAddress struct {
+	Street string
+	City   string
+}
+
+type policyUser struct {
+	Name     string
+	Email    string
+	Password string
+	APIToken *string
+	Home     policyAddress
+	Work     *policyAddress
+	Friends  []*policyUser
+}
+
+type policyTeam struct {
+	Name    string
+	Members []policyUser
+	Secret  string
+}
+
+func init() {
+	RegisterType("PolicyUser", policyUser{})
+	RegisterType("PolicyTeam", (*policyTeam)(nil))
+}
+
+func newPolicyTeam() *policyTeam {
+	token := "tok_123"
+	return &policyTeam{
+		Name: "Testers",
+		Members: []policyUser{{
+			Name:     "Testy Tester",
+			Email:    "testy@example.com",
+			Password: "hunter2",
+			APIToken: &token,
+			Home:     policyAddress{Street: "742 Evergreen Terrace", City: "Springfield"},
+			Work:     &policyAddress{Street: "100 Industrial Way", City: "Springfield"},
+			Friends:  []*policyUser{{Name: "Friendly Tester", Password: "hunter3"}},
+		}},
+		Secret: "s3cr3t",
+	}
+}
+
+func TestParsePolicy(t *testing.T) {
+	t.Run("with YAML, returns the policy", func(t *testing.T) {
+		p, err := ParsePolicy([]byte(`
+types:
+  PolicyUser:
+    - name: Password
+    - path: Home.Street
+      action: mask
+rules:
+  - glob: "*Token"
+    action: hash
+`))
+		require.NoError(t, err)
+		assert.Equal(t, &Policy{
+			Types: map[string][]Rule{"PolicyUser": {{Name: "Password"}, {Path: "Home.Street", Action: Mask}}},
+			Rules: []Rule{{Glob: "*Token", Action: Hash}},
+		}, p)
+	})
+
+	t.Run("with JSON, returns the policy", func(t *testing.T) {
+		p, err := ParsePolicy([]byte(`{"types": {"PolicyTeam": [{"name": "Secret", "action": "omit"}]}}`))
+		require.NoError(t, err)
+		assert.Equal(t, &Policy{Types: map[string][]Rule{"PolicyTeam": {{Name: "Secret", Action: Omit}}}}, p)
+	})
+
+	t.Run("with an empty document, returns an empty policy", func(t *testing.T) {
+		p, err := ParsePolicy(nil)
+		require.NoError(t, err)
+		assert.Equal(t, &Policy{}, p)
+	})
+
+	t.Run("with unknown keys, returns an error", func(t *testing.T) {
+		_, err := ParsePolicy([]byte("rules:\n  - nmae: Password\n"))
+		assert.ErrorContains(t, err, "nmae")
+		_, err = ParsePolicy([]byte(`{"rules": [{"nmae": "Password"}]}`))
+		assert.ErrorContains(t, err, "nmae")
+	})
+
+	t.Run("with an invalid policy, returns the validation error", func(t *testing.T) {
+		_, err := ParsePolicy([]byte("types:\n  Missing:\n    - name: Password\n"))
+		assert.EqualError(t, err, `scrub: policy names unregistered type "Missing"`)
+	})
+}
+
+func TestPolicyValidate(t *testing.T) {
+	t.Run("with rules that match fields of the registered types, returns nil", func(t *testing.T) {
+		p := Policy{Types: map[string][]Rule{
+			"PolicyUser": {{Name: "Password"}, {Path: "Work.Street"}, {Path: "Friends.Friends.Email"}, {Glob: "*Token"}},
+			"PolicyTeam": {{Path: "**.City"}, {Name: "Secret", Path: "Secret"}},
+		}}
+		assert.NoError(t, p.Validate())
+	})
+
+	t.Run("with an invalid rule, returns an error", func(t *testing.T) {
+		p := Policy{Rules: []Rule{{Name: "Password"}, {Action: Mask}}}
+		assert.EqualError(t, p.Validate(), "scrub: policy rule 2: rule needs a name, glob or path")
+
+		p = Policy{Types: map[string][]Rule{"PolicyUser": {{Name: "Password", Action: "shred"}}}}
+		assert.EqualError(t, p.Validate(), `scrub: policy rule 1 for type PolicyUser: unknown action "shred"`)
+	})
+
+	t.Run("with a rule that matches no fields of its type, returns an error", func(t *testing.T) {
+		for _, r := range []Rule{{Name: "Passwd"}, {Path: "Street"}, {Path: "Home.Zip"}, {Path: "Friends.Friends.Zip"}, {Glob: "*Secret"}} {
+			p := Policy{Types: map[string][]Rule{"PolicyUser": {r}}}
+			assert.EqualError(t, p.Validate(), "scrub: policy rule 1 for type PolicyUser matches no fields of scrub.policyUser")
+		}
+	})
+}
+
+func TestPolicyApply(t *testing.T) {
+	t.Run("with type rules, applies them to values of the type wherever they are", func(t *testing.T) {
+		p := Policy{Types: map[string][]Rule{
+			"PolicyUser": {{Name: "Password"}, {Path: "Home.Street", Action: Mask}, {Glob: "*Token", Action: Hash}},
+			"PolicyTeam": {{Name: "Secret", Action: Omit}},
+		}}
+		team := newPolicyTeam()
+		token := team.Members[0].APIToken
+
+		require.NoError(t, p.Apply(team))
+
+		expected := newPolicyTeam()
+		expected.Secret = ""
+		expected.Members[0].Password = ""
+		expected.Members[0].Home.Street = MaskPlaceholder
+		hashed := Hash.Replace("tok_123")
+		expected.Members[0].APIToken = &hashed
+		expected.Members[0].Friends[0].Password = ""
+		expected.Members[0].Friends[0].Home.Street = MaskPlaceholder
+		assert.Equal(t, expected, team)
+		assert.Equal(t, "tok_123", *token, "shared strings are left alone")
+	})
+
+	t.Run("with type rules, matches paths from the type", func(t *testing.T) {
+		p := Policy{Types: map[string][]Rule{"PolicyUser": {{Path: "Name"}}}}
+		team := newPolicyTeam()
+
+		require.NoError(t, p.Apply(team))
+
+		assert.Equal(t, "Testers", team.Name)
+		assert.Equal(t, "", team.Members[0].Name)
+		assert.Equal(t, "", team.Members[0].Friends[0].Name)
+	})
+
+	t.Run("with rules for any type, matches paths from the value passed in", func(t *testing.T) {
+		p := Policy{Rules: []Rule{{Path: "Members.*.City", Action: Fake}, {Path: "Name"}}}
+		team := newPolicyTeam()
+
+		require.NoError(t, p.Apply(team))
+
+		assert.Equal(t, "", team.Name)
+		assert.Equal(t, "Testy Tester", team.Members[0].Name)
+		assert.Equal(t, Fake.Replace("Springfield"), team.Members[0].Home.City)
+		assert.Equal(t, Fake.Replace("Springfield"), team.Members[0].Work.City)
+	})
+
+	t.Run("with type rules and rules for any type, prefers the type rules", func(t *testing.T) {
+		p := Policy{
+			Types: map[string][]Rule{"PolicyUser": {{Name: "Password", Action: Mask}}},
+			Rules: []Rule{{Name: "Password", Action: Hash}},
+		}
+		team := newPolicyTeam()
+
+		require.NoError(t, p.Apply(team))
+
+		assert.Equal(t, MaskPlaceholder, team.Members[0].Password)
+	})
+
+	t.Run("with options, uses them", func(t *testing.T) {
+		p := Policy{Rules: []Rule{{Name: "Password"}}}
+		team := newPolicyTeam()
+
+		err := p.ApplyContext(context.Background(), team, WithMaxNodes(3))
+		assert.ErrorIs(t, err, ErrMaxNodes)
+	})
+
+	t.Run("with parallelism, gives the same result", func(t *testing.T) {
+		p := Policy{Types: map[string][]Rule{"PolicyUser": {{Path: "Home.Street"}}}, Rules: []Rule{{Path: "Members.Email"}}}
+		team := newPolicyTeam()
+		for i := 0; i < 50; i++ {
+			team.Members = append(team.Members, newPolicyTeam().Members[0])
+		}
+
+		require.NoError(t, p.ApplyContext(context.Background(), team, WithParallelism(4, 10)))
+
+		for _, m := range team.Members {
+			assert.Equal(t, "", m.Home.Street)
+			assert.Equal(t, "", m.Email)
+			assert.Equal(t, "100 Industrial Way", m.Work.Street)
+		}
+	})
+
+	t.Run("with an unregistered type, returns an error", func(t *testing.T) {
+		p := Policy{Types: map[string][]Rule{"Missing": {{Name: "Password"}}}}
+		assert.EqualError(t, p.Apply(newPolicyTeam()), `scrub: policy names unregistered type "Missing"`)
+	})
+}
+
+func TestRegisterType(t *testing.T) {
+	t.Run("with the same name and type, does nothing", func(t *testing.T) {
+		assert.NotPanics(t, func() { RegisterType("PolicyUser", &policyUser{}) })
+	})
+
+	t.Run("with a name registered for a different type, panics", func(t *testing.T) {
+		assert.PanicsWithValue(t, `scrub: can't register scrub.policyTeam as "PolicyUser": already registered for scrub.policyUser`, func() {
+			RegisterType("PolicyUser", policyTeam{})
+		})
+	})
+
+	t.Run("with a non-struct type, panics", func(t *testing.T) {
+		assert.Panics(t, func() { RegisterType("Int", 1) })
+		assert.Panics(t, func() { RegisterType("Nil", nil) })
+	})
+}
@@ -0,0 +1,62 @@
+package scrub
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+// TagProfile scrubs values for one audience, such as logs or analytics, according to their struct tags.
+// See Profile.
+type TagProfile struct {
+	name string
+}
+
+// Profile returns the profile with the given name. It scrubs the fields whose `scrub` struct tags name the
+// profile, so that one struct definition can be scrubbed differently for different audiences. A tag lists
+// profiles separated by commas, each optionally followed by = and an action, which defaults to zero:
+//
+//	type User struct {
+//		Name  string
+//		Email string `scrub:"logs,analytics=hash,support=mask"`
+//	}
+//
+// Here Profile("logs") zeroes Email, Profile("analytics") hashes it, and Profile("support") masks it.
+// Tags of `scrub:"true"` are left to TaggedFields.
+func Profile(name string) TagProfile {
+	return TagProfile{name: name}
+}
+
+// Apply scrubs src, which should be a pointer to a struct, according to the profile. Omitted fields are
+// zeroed, since they can't be removed from a struct. It returns an error if a tag naming the profile has an
+// unknown action.
+func (p TagProfile) Apply(src any) error {
+	return p.ApplyContext(context.Background(), src)
+}
+
+// ApplyContext is like Apply, but accepts the same options as TaggedFieldsContext and stops early in the
+// same cases.
+func (p TagProfile) ApplyContext(ctx context.Context, src any, opts ...Option) error {
+	return scrub(ctx, src, walker{profile: p.name}, opts)
+}
+
+// profileAction returns the action for profile in a `scrub` struct tag, if the tag names it.
+func profileAction(tag, profile string) (Action, bool, error) {
+	for tag != "" {
+		var entry string
+		entry, tag, _ = strings.Cut(tag, ",")
+		name, action, hasAction := strings.Cut(strings.TrimSpace(entry), "=")
+		if name != profile {
+			continue
+		}
+		if !hasAction {
+			return Zero, true, nil
+		}
+		a, err := ParseAction(action)
+		if err != nil {
+			return "", false, fmt.Errorf("unknown action %q", action)
+		}
+		return a, true, nil
+	}
+	return "", false, nil
+}
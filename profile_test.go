@@ -0,0 +1,134 @@
+package scrub
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestProfile(t *testing.T) {
+	type address struct {
+		Street string `scrub:"logs,support=mask"`
+		City   string
+	}
+	type user struct {
+		Name     string `scrub:"analytics=fake"`
+		Email    string `scrub:"logs, analytics=hash, support=mask"`
+		Password string `scrub:"true"`
+		Age      int    `scrub:"analytics=mask"`
+		Home     *address
+		Friends  []user `scrub:"analytics=omit"`
+	}
+	newUser := func() *user {
+		return &user{
+			Name:     "Testy Tester",
+			Email:    "testy@example.com",
+			Password: "hunter2",
+			Age:      26,
+			Home:     &address{Street: "742 Evergreen Terrace", City: "Springfield"},
+			Friends:  []user{{Name: "Friendly Tester", Email: "friendly@example.com"}},
+		}
+	}
+
+	t.Run("with a bare profile name, zeroes the field", func(t *testing.T) {
+		u := newUser()
+		require.NoError(t, Profile("logs").Apply(u))
+
+		expected := newUser()
+		expected.Email = ""
+		expected.Home.Street = ""
+		expected.Friends[0].Email = ""
+		assert.Equal(t, expected, u)
+	})
+
+	t.Run("with actions, applies them", func(t *testing.T) {
+		u := newUser()
+		require.NoError(t, Profile("analytics").Apply(u))
+
+		expected := newUser()
+		expected.Name = Fake.Replace("Testy Tester")
+		expected.Email = Hash.Replace("testy@example.com")
+		expected.Age = 0
+		expected.Friends = nil
+		assert.Equal(t, expected, u)
+	})
+
+	t.Run("with another profile, applies its own actions", func(t *testing.T) {
+		u := newUser()
+		require.NoError(t, Profile("support").Apply(u))
+
+		assert.Equal(t, MaskPlaceholder, u.Email)
+		assert.Equal(t, MaskPlaceholder, u.Home.Street)
+		assert.Equal(t, "hunter2", u.Password)
+		assert.Equal(t, MaskPlaceholder, u.Friends[0].Email)
+	})
+
+	t.Run("with a profile no tag names, leaves the value alone", func(t *testing.T) {
+		u := newUser()
+		require.NoError(t, Profile("billing").Apply(u))
+		assert.Equal(t, newUser(), u)
+
+		require.NoError(t, Profile("true").Apply(u))
+		assert.Equal(t, "", u.Password, `"true" is a profile name like any other`)
+	})
+
+	t.Run("with an unknown action in a tag, returns an error", func(t *testing.T) {
+		type broken struct {
+			Email string `scrub:"logs=shred"`
+		}
+		err := Profile("logs").Apply(&broken{})
+		assert.EqualError(t, err, `scrub: tag on field Email: unknown action "shred"`)
+	})
+
+	t.Run("with options, uses them", func(t *testing.T) {
+		users := struct{ Users []user }{}
+		for i := 0; i < 100; i++ {
+			users.Users = append(users.Users, *newUser())
+		}
+
+		require.NoError(t, Profile("logs").ApplyContext(context.Background(), &users, WithParallelism(4, 10)))
+		for _, u := range users.Users {
+			assert.Equal(t, "", u.Email)
+		}
+
+		err := Profile("logs").ApplyContext(context.Background(), &users, WithMaxNodes(10))
+		assert.ErrorIs(t, err, ErrMaxNodes)
+	})
+}
+
+func TestProfileAction(t *testing.T) {
+	tests := []struct {
+		tag, profile string
+		action       Action
+		ok           bool
+	}{
+		{"logs", "logs", Zero, true},
+		{"logs,analytics=hash", "analytics", Hash, true},
+		{" logs , analytics=mask ", "analytics", Mask, true},
+		{"logs,analytics=hash", "support", "", false},
+		{"", "logs", "", false},
+		{"true", "logs", "", false},
+	}
+	for _, tt := range tests {
+		a, ok, err := profileAction(tt.tag, tt.profile)
+		require.NoError(t, err)
+		assert.Equal(t, tt.action, a, tt.tag)
+		assert.Equal(t, tt.ok, ok, tt.tag)
+	}
+}
+
+func FuzzProfileAction(f *testing.F) {
+	for _, tag := range []string{"", "true", "logs", "logs,analytics=hash", " support = mask ,", "x=unknown", "=,=="} {
+		f.Add(tag, "analytics")
+	}
+	f.Fuzz(func(t *testing.T, tag, profile string) {
+		a, ok, err := profileAction(tag, profile)
+		if err != nil || !ok {
+			return
+		}
+		_, err = ParseAction(string(a))
+		require.NoError(t, err, "profileAction(%q, %q) returned %q", tag, profile, a)
+	})
+}
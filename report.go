@@ -0,0 +1,119 @@
+package scrub
+
+import (
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// Report lists the fields that a scrub selected and replaced, including any that already held zero values.
+// It never includes their original values, so it's safe to log or keep for audits.
+type Report struct {
+	// Fields lists the scrubbed fields in the order they were visited.
+	Fields []ScrubbedField
+	// Counts maps each selector, as in ScrubbedField.Rule, to the number of fields it scrubbed.
+	Counts map[string]int
+}
+
+// ScrubbedField describes a field that a scrub replaced.
+type ScrubbedField struct {
+	// Path locates the field from the value passed in, using Go field names and slice indexes, as in
+	// "Members[2].Home.Street".
+	Path string
+	// Type is the Go type of the field, as in "*string".
+	Type string
+	// Action is what was done to the field.
+	Action Action
+	// Rule says what selected the field: "tag=true" for a `scrub:"true"` tag, "name=X" for a field named
+	// with NamedFields, "profile=X" for a field whose tag names the profile, "detect=X,Y" for a string
+	// changed by WithDetectors or WithPII, naming the detectors that found something, or the policy rule,
+	// as described by Rule.String and prefixed with "type=X" for rules of registered types.
+	Rule string
+}
+
+// WithReport fills r with the fields that were scrubbed, replacing anything r held before. When a scrub
+// stops early with an error, r lists the fields scrubbed up to that point.
+func WithReport(r *Report) Option {
+	return func(c *config) {
+		c.report = r
+	}
+}
+
+// WithDryRun fills r as WithReport does, but leaves every value unchanged. The traversal is the same as for
+// a real scrub, so r lists exactly the fields that would be scrubbed, which is useful for trying out new
+// names or policies on real data before rolling them out.
+func WithDryRun(r *Report) Option {
+	return func(c *config) {
+		c.report = r
+		c.dryRun = true
+	}
+}
+
+// Paths returns the paths of the fields in r, in order.
+func (r *Report) Paths() []string {
+	paths := make([]string, len(r.Fields))
+	for i, f := range r.Fields {
+		paths[i] = f.Path
+	}
+	return paths
+}
+
+// reportState tracks the path to the field being visited and the fields scrubbed so far. Like
+// policyState, it's kept behind a pointer so that appending to it doesn't make the walker escape.
+type reportState struct {
+	path   []pathElem
+	fields []ScrubbedField
+}
+
+// pathElem is a struct field name, or a slice index if name is empty.
+type pathElem struct {
+	name  string
+	index int
+}
+
+func (r *reportState) push(e pathElem) {
+	r.path = append(r.path, e)
+}
+
+func (r *reportState) pop() {
+	r.path = r.path[:len(r.path)-1]
+}
+
+func (r *reportState) record(field *reflect.StructField, a Action, rule string) {
+	var b strings.Builder
+	for i, e := range r.path {
+		if e.name == "" {
+			b.WriteByte('[')
+			b.WriteString(strconv.Itoa(e.index))
+			b.WriteByte(']')
+			continue
+		}
+		if i > 0 {
+			b.WriteByte('.')
+		}
+		b.WriteString(e.name)
+	}
+	r.fields = append(r.fields, ScrubbedField{
+		Path:   b.String(),
+		Type:   field.Type.String(),
+		Action: a,
+		Rule:   rule,
+	})
+}
+
+// fork returns a copy of r with no recorded fields for use by a worker goroutine.
+func (r *reportState) fork() *reportState {
+	if r == nil {
+		return nil
+	}
+	return &reportState{path: append([]pathElem(nil), r.path...)}
+}
+
+// fill sets dst to the fields recorded in r.
+func (r *reportState) fill(dst *Report) {
+	dst.Fields = r.fields
+	dst.Counts = make(map[string]int)
+	for _, f := range r.fields {
+		dst.Counts[f.Rule]++
+	}
+}
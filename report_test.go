@@ -0,0 +1,197 @@
+package scrub
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWithReport(t *testing.T) {
+	type card struct {
+		Number string `scrub:"true"`
+		Expiry string
+	}
+	type cardholder struct {
+		Name     string
+		Password string `scrub:"true"`
+		Cards    []*card
+		Backup   *card
+	}
+
+	t.Run("with tagged fields, lists the scrubbed fields without their values", func(t *testing.T) {
+		user := &cardholder{
+			Name:     "Testy Tester",
+			Password: "hunter2",
+			Cards:    []*card{{Number: "4111"}, nil, {Number: "4242"}},
+		}
+		var report Report
+
+		require.NoError(t, TaggedFieldsContext(context.Background(), user, WithReport(&report)))
+
+		assert.Equal(t, []ScrubbedField{
+			{Path: "Password", Type: "string", Action: Zero, Rule: "tag=true"},
+			{Path: "Cards[0].Number", Type: "string", Action: Zero, Rule: "tag=true"},
+			{Path: "Cards[2].Number", Type: "string", Action: Zero, Rule: "tag=true"},
+		}, report.Fields)
+		assert.Equal(t, map[string]int{"tag=true": 3}, report.Counts)
+		assert.NotContains(t, fmt.Sprintf("%+v", report), "hunter2")
+	})
+
+	t.Run("with named fields, names the field in the rule", func(t *testing.T) {
+		user := &cardholder{Name: "Testy Tester", Backup: &card{Expiry: "12/30"}}
+		var report Report
+
+		require.NoError(t, NamedFieldsContext(context.Background(), user, []string{"Name", "Expiry"}, WithReport(&report)))
+
+		assert.Equal(t, []ScrubbedField{
+			{Path: "Name", Type: "string", Action: Zero, Rule: "name=Name"},
+			{Path: "Backup.Expiry", Type: "string", Action: Zero, Rule: "name=Expiry"},
+		}, report.Fields)
+		assert.Equal(t, map[string]int{"name=Name": 1, "name=Expiry": 1}, report.Counts)
+	})
+
+	t.Run("with a policy, describes the rules and counts the fields per rule", func(t *testing.T) {
+		p := Policy{
+			Types: map[string][]Rule{"PolicyUser": {{Name: "Password"}, {Glob: "*Token", Action: Hash}}},
+			Rules: []Rule{{Path: "Secret", Action: Mask}},
+		}
+		var report Report
+
+		require.NoError(t, p.ApplyContext(context.Background(), newPolicyTeam(), WithReport(&report)))
+
+		assert.Equal(t, []ScrubbedField{
+			{Path: "Members[0].Password", Type: "string", Action: Zero, Rule: "type=PolicyUser name=Password"},
+			{Path: "Members[0].APIToken", Type: "*string", Action: Hash, Rule: "type=PolicyUser glob=*Token action=hash"},
+			{Path: "Members[0].Friends[0].Password", Type: "string", Action: Zero, Rule: "type=PolicyUser name=Password"},
+			{Path: "Secret", Type: "string", Action: Mask, Rule: "path=Secret action=mask"},
+		}, report.Fields)
+		assert.Equal(t, map[string]int{
+			"type=PolicyUser name=Password":           2,
+			"type=PolicyUser glob=*Token action=hash": 1,
+			"path=Secret action=mask":                 1,
+		}, report.Counts)
+	})
+
+	t.Run("with a profile, names the profile in the rule", func(t *testing.T) {
+		type user struct {
+			Name  string `scrub:"analytics=fake"`
+			Email string `scrub:"logs, analytics=hash"`
+		}
+		u := &user{Name: "Testy Tester", Email: "testy@example.com"}
+		var report Report
+
+		require.NoError(t, Profile("analytics").ApplyContext(context.Background(), u, WithReport(&report)))
+
+		require.NotEmpty(t, report.Fields)
+		assert.Equal(t, ScrubbedField{Path: "Name", Type: "string", Action: Fake, Rule: "profile=analytics"}, report.Fields[0])
+		assert.Equal(t, len(report.Fields), report.Counts["profile=analytics"])
+	})
+
+	t.Run("with a selected field that's already zero, lists it anyway", func(t *testing.T) {
+		var report Report
+
+		require.NoError(t, TaggedFieldsContext(context.Background(), &cardholder{}, WithReport(&report)))
+
+		assert.Equal(t, []ScrubbedField{{Path: "Password", Type: "string", Action: Zero, Rule: "tag=true"}}, report.Fields)
+	})
+
+	t.Run("with nothing scrubbed, returns an empty report", func(t *testing.T) {
+		report := Report{Fields: []ScrubbedField{{Path: "Stale"}}}
+
+		require.NoError(t, NamedFieldsContext(context.Background(), &card{Expiry: "12/30"}, []string{"CVV"}, WithReport(&report)))
+
+		assert.Empty(t, report.Fields)
+		assert.Empty(t, report.Counts)
+	})
+
+	t.Run("with parallelism, lists the fields in the same order as a sequential scrub", func(t *testing.T) {
+		newUser := func() *cardholder {
+			user := &cardholder{}
+			for i := 0; i < 100; i++ {
+				user.Cards = append(user.Cards, &card{Number: strings.Repeat("4", i+1)})
+			}
+			return user
+		}
+		var sequential, parallel Report
+
+		require.NoError(t, TaggedFieldsContext(context.Background(), newUser(), WithReport(&sequential)))
+		require.NoError(t, TaggedFieldsContext(context.Background(), newUser(), WithReport(&parallel), WithParallelism(4, 10)))
+
+		assert.Len(t, parallel.Fields, 101)
+		assert.Equal(t, "Cards[99].Number", parallel.Fields[100].Path)
+		assert.Equal(t, sequential, parallel)
+	})
+
+	t.Run("with an error, lists the fields scrubbed before it", func(t *testing.T) {
+		user := &cardholder{Password: "hunter2", Cards: []*card{{Number: "4111"}, {Number: "4242"}}}
+		var report Report
+
+		err := TaggedFieldsContext(context.Background(), user, WithReport(&report), WithMaxNodes(6))
+
+		require.True(t, errors.Is(err, ErrMaxNodes))
+		assert.Equal(t, []ScrubbedField{
+			{Path: "Password", Type: "string", Action: Zero, Rule: "tag=true"},
+			{Path: "Cards[0].Number", Type: "string", Action: Zero, Rule: "tag=true"},
+		}, report.Fields)
+	})
+}
+
+func TestWithDryRun(t *testing.T) {
+	type card struct {
+		Number string `scrub:"true"`
+		Expiry string
+	}
+	type cardholder struct {
+		Name     string
+		Password string `scrub:"true"`
+		Cards    []*card
+		Backup   *card
+	}
+
+	t.Run("with named fields, lists the paths that would be scrubbed without changing anything", func(t *testing.T) {
+		user := &cardholder{
+			Name:     "Testy Tester",
+			Password: "hunter2",
+			Cards:    []*card{{Number: "4111", Expiry: "12/30"}},
+			Backup:   &card{Number: "4242"},
+		}
+		var report Report
+
+		require.NoError(t, NamedFieldsContext(context.Background(), user, []string{"Password", "Number"}, WithDryRun(&report)))
+
+		assert.Equal(t, []string{"Password", "Cards[0].Number", "Backup.Number"}, report.Paths())
+		assert.Equal(t, map[string]int{"name=Password": 1, "name=Number": 2}, report.Counts)
+		assert.Equal(t, "hunter2", user.Password)
+		assert.Equal(t, "4111", user.Cards[0].Number)
+		assert.Equal(t, "4242", user.Backup.Number)
+	})
+
+	t.Run("with a selected struct, doesn't list the fields inside it", func(t *testing.T) {
+		user := &cardholder{Backup: &card{Number: "4242"}}
+		var dryRun, real Report
+
+		require.NoError(t, NamedFieldsContext(context.Background(), user, []string{"Backup"}, WithDryRun(&dryRun)))
+		require.NoError(t, NamedFieldsContext(context.Background(), user, []string{"Backup"}, WithReport(&real)))
+
+		assert.Equal(t, []string{"Backup"}, dryRun.Paths())
+		assert.Equal(t, real, dryRun)
+		assert.Nil(t, user.Backup)
+	})
+
+	t.Run("with a policy and parallelism, matches a real scrub", func(t *testing.T) {
+		p := Policy{Types: map[string][]Rule{"PolicyUser": {{Name: "Password"}, {Path: "Home.Street", Action: Mask}}}}
+		team := newPolicyTeam()
+		var dryRun, real Report
+
+		require.NoError(t, p.ApplyContext(context.Background(), team, WithDryRun(&dryRun), WithParallelism(2, 1)))
+
+		assert.Equal(t, newPolicyTeam(), team)
+		require.NoError(t, p.ApplyContext(context.Background(), team, WithReport(&real)))
+		assert.Equal(t, real, dryRun)
+	})
+}
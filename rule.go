@@ -0,0 +1,225 @@
+package scrub
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	pathpkg "path"
+	"strings"
+)
+
+// Action says what to do with a value selected for scrubbing.
+type Action string
+
+const (
+	// Zero replaces the value with its zero value.
+	Zero Action = "zero"
+	// Omit removes the value entirely, where the output format allows it, such as a key in a JSON object.
+	Omit Action = "omit"
+	// Mask replaces a string with a fixed placeholder, and any other value with its zero value.
+	Mask Action = "mask"
+	// Hash replaces a string with the hex-encoded SHA-256 hash of its contents, and any other value with its
+	// zero value. This lets you correlate values without revealing them, but short or predictable values,
+	// such as phone numbers, can be recovered by brute force.
+	Hash Action = "hash"
+	// Fake replaces a string with a made-up one of the same shape, with letters replaced by letters of the
+	// same case and digits by digits, and any other value with its zero value. The same string is always
+	// replaced with the same fake, so fakes can still be joined and grouped. Like Hash, it doesn't hide
+	// short or predictable values from a determined attacker.
+	Fake Action = "fake"
+	// Redact replaces the substrings of a string that look like PII, such as email addresses and card
+	// numbers, with MaskPlaceholder and keeps the rest, and replaces any other value with its zero value.
+	// Use RedactOnly, or a name such as "redact:email+phone" or "redact:jwt+entropy", to use other
+	// built-in detectors.
+	Redact Action = "redact"
+	// URL removes the password and the query parameters that commonly hold credentials, such as "password",
+	// "token" and "api_key", from a URL, and keeps the rest. It applies to strings and to url.URL and
+	// *url.URL values, which are masked unless they're URLs with a scheme and a host, so that DSNs such as
+	// "bob:secret@tcp(db:3306)/app" don't get through; any other value is replaced with its zero value. Use
+	// URLRemoving, or a name such as "url:session+sid", to remove more query parameters.
+	URL Action = "url"
+)
+
+// ParseAction returns the action with the given name, such as "mask".
+func ParseAction(name string) (Action, error) {
+	switch a := Action(name); a {
+	case Zero, Omit, Mask, Hash, Fake, Redact, URL:
+		return a, nil
+	default:
+		if _, ok := a.redactDetectors(); ok {
+			return a, nil
+		}
+		if _, ok := a.urlParams(); ok {
+			return a, nil
+		}
+		return "", fmt.Errorf("scrub: unknown action %q", name)
+	}
+}
+
+// MaskPlaceholder is the string that Mask replaces strings with.
+const MaskPlaceholder = "****"
+
+// Replace returns what the action replaces the string s with. Zero and Omit return the empty string.
+func (a Action) Replace(s string) string {
+	switch a {
+	case Mask:
+		return MaskPlaceholder
+	case Hash:
+		sum := sha256.Sum256([]byte(s))
+		return hex.EncodeToString(sum[:])
+	case Fake:
+		return fake(s)
+	default:
+		if detectors, ok := a.redactDetectors(); ok {
+			redacted, _ := detect(s, detectors)
+			return redacted
+		}
+		if params, ok := a.urlParams(); ok {
+			return scrubURL(s, params)
+		}
+		return ""
+	}
+}
+
+// fake returns a string of the same shape as s, chosen by a generator seeded with a hash of s.
+func fake(s string) string {
+	sum := sha256.Sum256([]byte(s))
+	rng := rand.New(rand.NewChaCha8(sum))
+	b := []byte(s)
+	for i, c := range b {
+		switch {
+		case 'a' <= c && c <= 'z':
+			b[i] = 'a' + byte(rng.IntN(26))
+		case 'A' <= c && c <= 'Z':
+			b[i] = 'A' + byte(rng.IntN(26))
+		case '0' <= c && c <= '9':
+			b[i] = '0' + byte(rng.IntN(10))
+		}
+	}
+	return string(b)
+}
+
+// Rule selects values to scrub by name, by glob, by path, or a combination, and says what to do with them.
+type Rule struct {
+	// Name selects fields, or keys, with the given name at any depth.
+	Name string `json:"name,omitempty" yaml:"name,omitempty"`
+	// Glob selects fields, or keys, whose names match the given pattern at any depth, such as "*_token". The
+	// syntax is that of path.Match.
+	Glob string `json:"glob,omitempty" yaml:"glob,omitempty"`
+	// Path selects the field at the given dotted path of names from the top-level value, such as
+	// "user.address.street". A "*" element matches any single name, and a "**" element matches any number
+	// of names, including none. Slice and array elements don't add to the path.
+	Path string `json:"path,omitempty" yaml:"path,omitempty"`
+	// Action is what to do with the selected values. It defaults to Zero.
+	Action Action `json:"action,omitempty" yaml:"action,omitempty"`
+}
+
+// Matches reports whether the rule selects the field with the given name found at path, which ends with
+// the field's name. If more than one of Name, Glob and Path are set, the field must match all of them. A
+// rule with none of them set matches nothing.
+func (r Rule) Matches(name string, path []string) bool {
+	if r.Name == "" && r.Glob == "" && r.Path == "" {
+		return false
+	}
+	return r.matchesName(name) && (r.Path == "" || matchPath(r.Path, path))
+}
+
+// matchesName reports whether name matches the rule's Name and Glob, if they're set.
+func (r Rule) matchesName(name string) bool {
+	if r.Name != "" && r.Name != name {
+		return false
+	}
+	if r.Glob != "" {
+		if ok, _ := pathpkg.Match(r.Glob, name); !ok {
+			return false
+		}
+	}
+	return true
+}
+
+// Validate returns an error if the rule doesn't select anything, has a malformed glob or has an unknown
+// action.
+func (r Rule) Validate() error {
+	if err := r.validate(); err != nil {
+		return fmt.Errorf("scrub: %w", err)
+	}
+	return nil
+}
+
+// validate is Validate without the package prefix, for callers that add their own, such as Policy.Validate.
+func (r Rule) validate() error {
+	if r.Name == "" && r.Glob == "" && r.Path == "" {
+		return errors.New("rule needs a name, glob or path")
+	}
+	if r.Glob != "" {
+		if _, err := pathpkg.Match(r.Glob, ""); err != nil {
+			return fmt.Errorf("bad glob %q: %w", r.Glob, err)
+		}
+	}
+	if r.Action != "" {
+		if _, err := ParseAction(string(r.Action)); err != nil {
+			return fmt.Errorf("unknown action %q", r.Action)
+		}
+	}
+	return nil
+}
+
+// String describes the rule, such as "name=Password action=mask". Reports use it to say which rule
+// scrubbed a field.
+func (r Rule) String() string {
+	var parts []string
+	for _, part := range []struct{ key, value string }{
+		{"name", r.Name},
+		{"glob", r.Glob},
+		{"path", r.Path},
+		{"action", string(r.Action)},
+	} {
+		if part.value != "" {
+			parts = append(parts, part.key+"="+part.value)
+		}
+	}
+	return strings.Join(parts, " ")
+}
+
+// ActionOrDefault returns the rule's action, or Zero if it isn't set.
+func (r Rule) ActionOrDefault() Action {
+	if r.Action == "" {
+		return Zero
+	}
+	return r.Action
+}
+
+// matchPath reports whether path matches the dotted pattern, whose elements may be "*" or "**" wildcards.
+// It walks the pattern in place rather than splitting it, so that matching doesn't allocate.
+func matchPath(pattern string, path []string) bool {
+	for {
+		elem, rest, more := strings.Cut(pattern, ".")
+		switch elem {
+		case "**":
+			if !more {
+				return true
+			}
+			for i := 0; i <= len(path); i++ {
+				if matchPath(rest, path[i:]) {
+					return true
+				}
+			}
+			return false
+		case "*":
+			if len(path) == 0 {
+				return false
+			}
+		default:
+			if len(path) == 0 || elem != path[0] {
+				return false
+			}
+		}
+		path = path[1:]
+		if !more {
+			return len(path) == 0
+		}
+		pattern = rest
+	}
+}
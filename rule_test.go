@@ -0,0 +1,129 @@
+package scrub
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestActionReplace(t *testing.T) {
+	t.Run("with Zero or Omit, returns the empty string", func(t *testing.T) {
+		assert.Equal(t, "", Zero.Replace("hunter2"))
+		assert.Equal(t, "", Omit.Replace("hunter2"))
+	})
+
+	t.Run("with Mask, returns the placeholder", func(t *testing.T) {
+		assert.Equal(t, MaskPlaceholder, Mask.Replace("hunter2"))
+		assert.Equal(t, MaskPlaceholder, Mask.Replace(""))
+	})
+
+	t.Run("with Hash, returns the hex-encoded SHA-256 hash", func(t *testing.T) {
+		assert.Equal(t, "f52fbd32b2b3b86ff88ef6c490628285f482af15ddcb29541f94bcf526a3f6c7", Hash.Replace("hunter2"))
+	})
+
+	t.Run("with Redact, replaces the PII and keeps the rest", func(t *testing.T) {
+		assert.Equal(t, "mail **** or call ****", Redact.Replace("mail testy@example.com or call (555) 123-4567"))
+		assert.Equal(t, "mail **** or call (555) 123-4567", RedactOnly(Email).Replace("mail testy@example.com or call (555) 123-4567"))
+	})
+}
+
+func TestActionReplaceFake(t *testing.T) {
+	t.Run("with Fake, returns a string of the same shape", func(t *testing.T) {
+		faked := Fake.Replace("Testy Tester <testy+1@example.com>, 555-0123")
+		assert.Regexp(t, `^[A-Z][a-z]{4} [A-Z][a-z]{5} <[a-z]{5}\+\d@[a-z]{7}\.[a-z]{3}>, \d{3}-\d{4}$`, faked)
+		assert.NotEqual(t, "Testy Tester <testy+1@example.com>, 555-0123", faked)
+	})
+
+	t.Run("with Fake, always returns the same fake for the same string", func(t *testing.T) {
+		assert.Equal(t, Fake.Replace("hunter2"), Fake.Replace("hunter2"))
+		assert.NotEqual(t, Fake.Replace("hunter2"), Fake.Replace("hunter3"))
+	})
+
+	t.Run("with Fake and non-ASCII letters, leaves them alone", func(t *testing.T) {
+		assert.Equal(t, "é-ü", Fake.Replace("é-ü"))
+	})
+}
+
+func TestParseAction(t *testing.T) {
+	t.Run("with a known action, returns it", func(t *testing.T) {
+		for _, a := range []Action{Zero, Omit, Mask, Hash, Fake, Redact, "redact:email+ipv4"} {
+			parsed, err := ParseAction(string(a))
+			assert.NoError(t, err)
+			assert.Equal(t, a, parsed)
+		}
+	})
+
+	t.Run("with an unknown action, returns an error", func(t *testing.T) {
+		_, err := ParseAction("shred")
+		assert.EqualError(t, err, `scrub: unknown action "shred"`)
+
+		_, err = ParseAction("redact:email+dna")
+		assert.EqualError(t, err, `scrub: unknown action "redact:email+dna"`)
+	})
+}
+
+func TestRuleValidate(t *testing.T) {
+	t.Run("with a valid rule, returns nil", func(t *testing.T) {
+		assert.NoError(t, Rule{Name: "password"}.Validate())
+		assert.NoError(t, Rule{Glob: "*_token", Action: Omit}.Validate())
+		assert.NoError(t, Rule{Path: "user.**", Action: Hash}.Validate())
+	})
+
+	t.Run("with an invalid rule, returns an error", func(t *testing.T) {
+		assert.EqualError(t, Rule{Action: Mask}.Validate(), "scrub: rule needs a name, glob or path")
+		assert.ErrorContains(t, Rule{Glob: "["}.Validate(), `scrub: bad glob "["`)
+		assert.EqualError(t, Rule{Name: "password", Action: "shred"}.Validate(), `scrub: unknown action "shred"`)
+	})
+}
+
+func TestRuleMatches(t *testing.T) {
+	path := []string{"user", "address", "street"}
+
+	t.Run("with a name, matches fields with that name at any depth", func(t *testing.T) {
+		assert.True(t, Rule{Name: "street"}.Matches("street", path))
+		assert.False(t, Rule{Name: "city"}.Matches("street", path))
+	})
+
+	t.Run("with a glob, matches fields whose names match it at any depth", func(t *testing.T) {
+		assert.True(t, Rule{Glob: "str*"}.Matches("street", path))
+		assert.True(t, Rule{Glob: "*_token"}.Matches("api_token", []string{"api_token"}))
+		assert.False(t, Rule{Glob: "*_token"}.Matches("street", path))
+		assert.False(t, Rule{Glob: "[", Path: "**"}.Matches("street", path))
+	})
+
+	t.Run("with a path, matches only the field at that path", func(t *testing.T) {
+		assert.True(t, Rule{Path: "user.address.street"}.Matches("street", path))
+		assert.False(t, Rule{Path: "address.street"}.Matches("street", path))
+		assert.False(t, Rule{Path: "user.address"}.Matches("street", path))
+	})
+
+	t.Run("with wildcards in the path, matches any names", func(t *testing.T) {
+		assert.True(t, Rule{Path: "user.*.street"}.Matches("street", path))
+		assert.False(t, Rule{Path: "*.street"}.Matches("street", path))
+		assert.True(t, Rule{Path: "**.street"}.Matches("street", path))
+		assert.True(t, Rule{Path: "user.**.street"}.Matches("street", path))
+		assert.True(t, Rule{Path: "**"}.Matches("street", path))
+		assert.False(t, Rule{Path: "**.city"}.Matches("street", path))
+	})
+
+	t.Run("with a name and a path, matches fields that match both", func(t *testing.T) {
+		assert.True(t, Rule{Name: "street", Path: "user.**"}.Matches("street", path))
+		assert.False(t, Rule{Name: "street", Path: "account.**"}.Matches("street", path))
+	})
+
+	t.Run("with none of a name, a glob or a path, matches nothing", func(t *testing.T) {
+		assert.False(t, Rule{}.Matches("street", path))
+	})
+
+	t.Run("without an action, defaults to Zero", func(t *testing.T) {
+		assert.Equal(t, Zero, Rule{Name: "street"}.ActionOrDefault())
+		assert.Equal(t, Hash, Rule{Name: "street", Action: Hash}.ActionOrDefault())
+	})
+}
+
+func TestRuleString(t *testing.T) {
+	t.Run("with some fields set, describes those fields", func(t *testing.T) {
+		assert.Equal(t, "name=Password", Rule{Name: "Password"}.String())
+		assert.Equal(t, "glob=*Token path=User.** action=hash", Rule{Glob: "*Token", Path: "User.**", Action: Hash}.String())
+	})
+}
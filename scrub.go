@@ -1,8 +1,16 @@
 package scrub
 
 import (
+	"context"
+	"errors"
+	"fmt"
 	"reflect"
+	"runtime"
 	"slices"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"unsafe"
 )
 
 // TaggedFields takes a struct and recursively sets all fields annotated with a `scrub:"true"`
@@ -10,9 +18,13 @@ import (
 //
 // This function is a no-op for non-struct types.
 func TaggedFields(src any) {
-	scrub(src, func(field reflect.StructField) bool {
-		return field.Tag.Get("scrub") == "true"
-	})
+	_ = scrub(context.Background(), src, walker{tagged: true}, nil)
+}
+
+// TaggedFieldsContext is like TaggedFields, but accepts options and stops early if ctx is canceled, in
+// which case it returns ctx.Err(), or if a limit set with WithMaxNodes or WithMaxDepth is exceeded.
+func TaggedFieldsContext(ctx context.Context, src any, opts ...Option) error {
+	return scrub(ctx, src, walker{tagged: true}, opts)
 }
 
 // NamedFields takes a struct and sets all fields with the given names to their zero value. This is useful
@@ -20,85 +32,541 @@ func TaggedFields(src any) {
 //
 // This function is a no-op for non-struct types.
 func NamedFields(src any, names ...string) {
-	scrub(src, func(field reflect.StructField) bool {
-		return slices.Contains(names, field.Name)
-	})
+	_ = scrub(context.Background(), src, walker{names: names}, nil)
+}
+
+// NamedFieldsContext is like NamedFields, but accepts options and stops early if ctx is canceled, in
+// which case it returns ctx.Err(), or if a limit set with WithMaxNodes or WithMaxDepth is exceeded.
+func NamedFieldsContext(ctx context.Context, src any, names []string, opts ...Option) error {
+	return scrub(ctx, src, walker{names: names}, opts)
+}
+
+// Option configures the behavior of TaggedFieldsContext and NamedFieldsContext.
+type Option func(*config)
+
+type config struct {
+	workers    int
+	threshold  int
+	maxNodes   int
+	maxDepth   int
+	unexported bool
+	report     *Report
+	dryRun     bool
+	// detectors are run on the strings that aren't otherwise selected, for WithDetectors.
+	detectors []Detector
+	cycles    bool
+}
+
+var (
+	// ErrMaxNodes is returned, wrapped with details, when a scrub visits more nodes than allowed by
+	// WithMaxNodes.
+	ErrMaxNodes = errors.New("scrub: maximum number of nodes exceeded")
+	// ErrMaxDepth is returned, wrapped with details, when a scrub descends deeper than allowed by
+	// WithMaxDepth.
+	ErrMaxDepth = errors.New("scrub: maximum depth exceeded")
+)
+
+// WithParallelism scrubs the elements of any slice with at least threshold elements using up to workers
+// goroutines. If workers is zero or negative, runtime.GOMAXPROCS(0) is used, and a threshold below 1 is
+// treated as 1. Each worker handles a contiguous range of the slice, so the result is the same as a
+// sequential scrub, but elements must not share pointers to the same struct. Slices nested inside those
+// elements are scrubbed sequentially.
+func WithParallelism(workers, threshold int) Option {
+	return func(c *config) {
+		if workers <= 0 {
+			workers = runtime.GOMAXPROCS(0)
+		}
+		c.workers = workers
+		c.threshold = max(threshold, 1)
+	}
+}
+
+// WithMaxNodes stops the scrub with an error wrapping ErrMaxNodes once more than n nodes have been visited.
+// Every struct field, and every element of a slice of structs or pointers, counts as a node. When scrubbing
+// in parallel, the limit is enforced approximately.
+func WithMaxNodes(n int) Option {
+	return func(c *config) {
+		c.maxNodes = n
+	}
+}
+
+// WithMaxDepth stops the scrub with an error wrapping ErrMaxDepth if it finds a struct nested more than n
+// levels below the one passed in, such as in a long or cyclic chain of pointers.
+func WithMaxDepth(n int) Option {
+	return func(c *config) {
+		c.maxDepth = n
+	}
 }
 
-func scrub(src any, shouldScrubFn func(field reflect.StructField) bool) {
+// WithUnexportedFields also scrubs unexported fields, including unexported struct pointers and slices, which
+// are otherwise left alone because reflection can't modify them. It uses package unsafe to get around that
+// restriction, so only use it for types whose invariants don't depend on those fields, such as third-party
+// types holding secrets in private fields. Unexported fields of a struct passed by value rather than by
+// pointer are still left alone, since they can't be modified at all.
+func WithUnexportedFields() Option {
+	return func(c *config) {
+		c.unexported = true
+	}
+}
+
+// WithCycleDetection keeps track of the structs reached through pointers, so that each of them is scrubbed
+// only once, and values with cycles, such as a node pointing to its parent, can be scrubbed at all. Without
+// it, a cycle is followed until WithMaxDepth stops it, or forever. It costs a map lookup for every pointer
+// to a struct.
+func WithCycleDetection() Option {
+	return func(c *config) {
+		c.cycles = true
+	}
+}
+
+// cancelCheckInterval is the number of nodes visited between checks for cancellation.
+const cancelCheckInterval = 256
+
+func newConfig(opts []Option) *config {
+	c := &config{}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
+}
+
+func scrub(ctx context.Context, src any, w walker, opts []Option) error {
 	if src == nil {
-		return
+		return nil
 	}
 	v := reflect.ValueOf(src)
 	if v.Kind() == reflect.Ptr {
 		v = v.Elem()
 	}
 	if v.Kind() != reflect.Struct {
-		return
+		return nil
 	}
 
-	for i := 0; i < v.NumField(); i++ {
-		field := v.Field(i)
-		structField := v.Type().Field(i)
+	if len(opts) > 0 {
+		w.config = *newConfig(opts)
+	}
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	if w.cycles {
+		w.seen = map[pointer]bool{}
+		if src := reflect.ValueOf(src); src.Kind() == reflect.Ptr {
+			w.enter(src)
+		}
+	}
+	if w.config.report == nil {
+		return w.walkStruct(ctx, v)
+	}
+	w.report = &reportState{}
+	err := w.walkStruct(ctx, v)
+	w.report.fill(w.config.report)
+	return err
+}
 
-		switch field.Kind() {
-		case reflect.Struct:
-			if shouldScrubFn(structField) {
-				zero := reflect.Zero(field.Type())
-				field.Set(zero)
-				continue
+type walker struct {
+	config
+	// tagged and names select the fields to scrub.
+	tagged bool
+	names  []string
+	// profile, if set, selects the fields whose scrub tags name it, as in `scrub:"logs,analytics=hash"`.
+	profile string
+	// policy, if set, selects more fields to scrub and says what to do with them. It's kept behind a
+	// pointer because appending to slices in the walker itself would make the names escape to the heap.
+	policy *policyState
+	// inWorker is set for walkers that scrub part of a slice in parallel, so that nested slices don't
+	// start more workers.
+	inWorker bool
+	// report, if set, records the fields that are scrubbed for WithReport.
+	report *reportState
+	// seen, if set, holds the pointers to structs already walked, for WithCycleDetection.
+	seen map[pointer]bool
+
+	depth int
+	nodes int
+	// total is shared by parallel workers, which add their node counts to it every cancelCheckInterval
+	// nodes.
+	total *atomic.Int64
+}
+
+// action returns the action for field, if it's selected for scrubbing.
+func (w *walker) action(field *reflect.StructField) (Action, bool) {
+	if w.tagged && field.Tag.Get("scrub") == "true" {
+		return Zero, true
+	}
+	if slices.Contains(w.names, field.Name) {
+		return Zero, true
+	}
+	if w.policy != nil {
+		if r, _, ok := w.policy.match(field.Name); ok {
+			return r.ActionOrDefault(), true
+		}
+	}
+	return "", false
+}
+
+// selector describes what selected field for scrubbing, for reports. It's only called for fields that are
+// selected, and only when reporting, so that the descriptions aren't built otherwise.
+func (w *walker) selector(field *reflect.StructField) string {
+	if w.tagged && field.Tag.Get("scrub") == "true" {
+		return "tag=true"
+	}
+	if slices.Contains(w.names, field.Name) {
+		return Rule{Name: field.Name}.String()
+	}
+	if w.policy != nil {
+		if r, typeName, ok := w.policy.match(field.Name); ok {
+			if typeName != "" {
+				return "type=" + typeName + " " + r.String()
 			}
+			return r.String()
+		}
+	}
+	return "profile=" + w.profile
+}
 
-			scrub(field.Addr().Interface(), shouldScrubFn)
-		case reflect.Ptr:
-			if field.IsNil() {
-				continue
+// apply replaces the value of field according to a. Strings and pointers to strings are replaced using
+// a.Replace, URLs are scrubbed by the URL action, and everything else is zeroed.
+func apply(field reflect.Value, a Action) {
+	if a != Zero && a != Omit {
+		switch {
+		case field.Kind() == reflect.String:
+			field.SetString(a.Replace(field.String()))
+			return
+		case field.Kind() == reflect.Ptr && field.Type().Elem().Kind() == reflect.String && !field.IsNil():
+			// Point to a new string, since the old one may be shared.
+			p := reflect.New(field.Type().Elem())
+			p.Elem().SetString(a.Replace(field.Elem().String()))
+			field.Set(p)
+			return
+		}
+		if applyURL(field, a) {
+			return
+		}
+	}
+	field.Set(reflect.Zero(field.Type()))
+}
+
+// detectField runs the detectors on field, if it's a string, a pointer to a string, or a slice of strings or
+// bytes, for WithDetectors. Only what's changed is replaced, with new pointers and slices, since the old ones
+// may be shared.
+func (w *walker) detectField(field reflect.Value, structField *reflect.StructField) {
+	var found []string
+	switch {
+	case field.Kind() == reflect.String:
+		if redacted, names := detect(field.String(), w.detectors); names != nil {
+			found = names
+			if !w.dryRun {
+				field.SetString(redacted)
 			}
-			if field.Elem().Kind() == reflect.Struct {
-				if shouldScrubFn(structField) {
-					zero := reflect.Zero(field.Type())
-					field.Set(zero)
-					continue
-				} else {
-					if !structField.IsExported() {
-						// Avoids "cannot return value obtained from unexported field or method" error
-						continue
-					}
-					scrub(field.Interface(), shouldScrubFn)
-					return
-				}
+		}
+	case field.Kind() == reflect.Ptr && field.Type().Elem().Kind() == reflect.String:
+		if redacted, names := detect(field.Elem().String(), w.detectors); names != nil {
+			found = names
+			if !w.dryRun {
+				p := reflect.New(field.Type().Elem())
+				p.Elem().SetString(redacted)
+				field.Set(p)
 			}
-		case reflect.Slice:
-			if field.IsNil() {
-				continue
+		}
+	case field.Kind() == reflect.Slice && field.Type().Elem().Kind() == reflect.Uint8:
+		if redacted, names := detect(string(field.Bytes()), w.detectors); names != nil {
+			found = names
+			if !w.dryRun {
+				field.SetBytes([]byte(redacted))
 			}
-			if shouldScrubFn(structField) {
-				zero := reflect.Zero(field.Type())
-				field.Set(zero)
+		}
+	case field.Kind() == reflect.Slice && field.Type().Elem().Kind() == reflect.String:
+		var copied reflect.Value
+		for i := 0; i < field.Len(); i++ {
+			redacted, names := detect(field.Index(i).String(), w.detectors)
+			if names == nil {
 				continue
 			}
-			for j := 0; j < field.Len(); j++ {
-				sliceField := field.Index(j)
-				if sliceField.Kind() == reflect.Struct {
-					scrub(sliceField.Addr().Interface(), shouldScrubFn)
-				}
-				if sliceField.Kind() == reflect.Ptr {
-					if field.Index(j).IsNil() {
-						continue
-					}
-					if sliceField.Elem().Kind() == reflect.Struct {
-						scrub(sliceField.Interface(), shouldScrubFn)
-					}
-				}
+			found = appendNew(found, names)
+			if !copied.IsValid() {
+				copied = reflect.MakeSlice(field.Type(), field.Len(), field.Len())
+				reflect.Copy(copied, field)
 			}
-			continue
-		default:
-			structField := v.Type().Field(i)
-			if shouldScrubFn(structField) {
-				zero := reflect.Zero(field.Type())
-				field.Set(zero)
+			copied.Index(i).SetString(redacted)
+		}
+		if copied.IsValid() && !w.dryRun {
+			field.Set(copied)
+		}
+	}
+	if found != nil && w.report != nil {
+		w.report.record(structField, Redact, "detect="+strings.Join(found, ","))
+	}
+}
+
+// appendNew appends the elements of names that aren't in list yet.
+func appendNew(list, names []string) []string {
+	for _, name := range names {
+		if !slices.Contains(list, name) {
+			list = append(list, name)
+		}
+	}
+	return list
+}
+
+// visit counts a node against the limit set with WithMaxNodes and periodically checks for cancellation.
+func (w *walker) visit(ctx context.Context, t reflect.Type) error {
+	w.nodes++
+	if w.nodes%cancelCheckInterval == 0 {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if w.total != nil {
+			w.total.Add(cancelCheckInterval)
+		}
+	}
+	if w.maxNodes > 0 && w.count() > w.maxNodes {
+		return fmt.Errorf("%w: visited more than %d nodes while scrubbing %s", ErrMaxNodes, w.maxNodes, t)
+	}
+	return nil
+}
+
+// count returns the number of nodes visited so far, including those visited by other workers.
+func (w *walker) count() int {
+	if w.total != nil {
+		return int(w.total.Load()) + w.nodes%cancelCheckInterval
+	}
+	return w.nodes
+}
+
+func (w *walker) walkStruct(ctx context.Context, v reflect.Value) error {
+	if w.maxDepth > 0 && w.depth > w.maxDepth {
+		return fmt.Errorf("%w: %s is nested more than %d levels deep", ErrMaxDepth, v.Type(), w.maxDepth)
+	}
+	scoped := w.policy != nil && w.policy.enter(v.Type())
+	w.depth++
+	err := w.walkFields(ctx, v)
+	w.depth--
+	if scoped {
+		w.policy.leave()
+	}
+	return err
+}
+
+func (w *walker) walkFields(ctx context.Context, v reflect.Value) error {
+	for i := 0; i < v.NumField(); i++ {
+		if err := w.visit(ctx, v.Type()); err != nil {
+			return err
+		}
+
+		field := v.Field(i)
+		structField := v.Type().Field(i)
+		if !structField.IsExported() {
+			// Avoids "cannot return value obtained from unexported field or method" error. Fields of
+			// structs that aren't addressable, such as one passed by value, can't be reached even with
+			// package unsafe.
+			if !w.unexported || !field.CanAddr() {
+				continue
+			}
+			field = settable(field)
+		}
+
+		if w.policy != nil {
+			w.policy.path = append(w.policy.path, structField.Name)
+		}
+		if w.report != nil {
+			w.report.push(pathElem{name: structField.Name})
+		}
+		err := w.walkField(ctx, field, &structField)
+		if w.report != nil {
+			w.report.pop()
+		}
+		if w.policy != nil {
+			w.policy.path = w.policy.path[:len(w.policy.path)-1]
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (w *walker) walkField(ctx context.Context, field reflect.Value, structField *reflect.StructField) error {
+	if k := field.Kind(); (k == reflect.Ptr || k == reflect.Slice) && field.IsNil() {
+		return nil
+	}
+	a, ok := w.action(structField)
+	if !ok && w.profile != "" {
+		var err error
+		if a, ok, err = profileAction(structField.Tag.Get("scrub"), w.profile); err != nil {
+			return fmt.Errorf("scrub: tag on field %s: %w", structField.Name, err)
+		}
+	}
+	if ok {
+		if !w.dryRun {
+			apply(field, a)
+		}
+		if w.report != nil {
+			w.report.record(structField, a, w.selector(structField))
+		}
+		return nil
+	}
+	if len(w.detectors) > 0 {
+		w.detectField(field, structField)
+	}
+
+	switch field.Kind() {
+	case reflect.Struct:
+		return w.walkStruct(ctx, field)
+	case reflect.Ptr:
+		if field.Elem().Kind() == reflect.Struct && w.enter(field) {
+			return w.walkStruct(ctx, field.Elem())
+		}
+	case reflect.Slice:
+		return w.walkSlice(ctx, field)
+	}
+	return nil
+}
+
+// enter reports whether the struct that p points to should be walked, which it should unless cycles are
+// being detected and it's been walked already.
+func (w *walker) enter(p reflect.Value) bool {
+	if w.seen == nil {
+		return true
+	}
+	key := pointer{addr: p.Pointer(), typ: p.Type()}
+	if w.seen[key] {
+		return false
+	}
+	w.seen[key] = true
+	return true
+}
+
+func (w *walker) walkSlice(ctx context.Context, s reflect.Value) error {
+	if k := s.Type().Elem().Kind(); k != reflect.Struct && k != reflect.Ptr {
+		return nil
+	}
+	if w.workers > 1 && !w.inWorker && s.Len() >= w.threshold {
+		return w.walkSliceParallel(ctx, s)
+	}
+	return w.walkElements(ctx, s, 0, s.Len())
+}
+
+// walkSliceParallel splits s into contiguous ranges and scrubs each of them in its own goroutine. The first
+// error cancels the remaining workers.
+func (w *walker) walkSliceParallel(ctx context.Context, s reflect.Value) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	n := s.Len()
+	if n == 0 {
+		return nil
+	}
+	workers := min(w.workers, n)
+	chunk := (n + workers - 1) / workers
+
+	var (
+		wg       sync.WaitGroup
+		errOnce  sync.Once
+		firstErr error
+		total    atomic.Int64
+		forked   []*walker
+	)
+	total.Store(int64(w.nodes))
+	for from := 0; from < n; from += chunk {
+		worker := w.fork(&total)
+		forked = append(forked, worker)
+		to := min(from+chunk, n)
+
+		wg.Add(1)
+		go func(from, to int) {
+			defer wg.Done()
+			err := worker.walkElements(ctx, s, from, to)
+			total.Add(int64(worker.nodes % cancelCheckInterval))
+			if err != nil {
+				errOnce.Do(func() {
+					firstErr = err
+					cancel()
+				})
 			}
-			continue
+		}(from, to)
+	}
+	wg.Wait()
+	w.nodes = int(total.Load())
+	if w.report != nil {
+		// Merge in chunk order, so the report is the same as for a sequential scrub.
+		for _, worker := range forked {
+			w.report.fields = append(w.report.fields, worker.report.fields...)
 		}
 	}
+	return firstErr
+}
+
+// settable returns a settable reference to the unexported struct field v, which must be addressable.
+func settable(v reflect.Value) reflect.Value {
+	return reflect.NewAt(v.Type(), unsafe.Pointer(v.UnsafeAddr())).Elem()
+}
+
+// fork returns a copy of w for use by a worker goroutine. The fields are copied individually, and the
+// slices are cloned, so that the caller's data only escapes to the heap when scrubbing in parallel. The
+// config's report is left out, since workers record into their own reportState.
+func (w *walker) fork(total *atomic.Int64) *walker {
+	return &walker{
+		config: config{
+			workers:    w.workers,
+			threshold:  w.threshold,
+			maxNodes:   w.maxNodes,
+			maxDepth:   w.maxDepth,
+			unexported: w.unexported,
+			dryRun:     w.dryRun,
+			detectors:  append([]Detector(nil), w.detectors...),
+			cycles:     w.cycles,
+		},
+		tagged:   w.tagged,
+		names:    append([]string(nil), w.names...),
+		profile:  strings.Clone(w.profile),
+		policy:   w.policy.fork(),
+		report:   w.report.fork(),
+		seen:     forkSeen(w.seen),
+		inWorker: true,
+		depth:    w.depth,
+		total:    total,
+	}
+}
+
+// forkSeen returns a copy of seen for a worker goroutine, or nil if cycles aren't being detected. Unlike
+// maps.Clone, it doesn't make the walker escape to the heap.
+func forkSeen(seen map[pointer]bool) map[pointer]bool {
+	if seen == nil {
+		return nil
+	}
+	forked := make(map[pointer]bool, len(seen))
+	for key := range seen {
+		forked[key] = true
+	}
+	return forked
+}
+
+func (w *walker) walkElements(ctx context.Context, s reflect.Value, from, to int) error {
+	for j := from; j < to; j++ {
+		if err := w.visit(ctx, s.Type()); err != nil {
+			return err
+		}
+
+		if w.report != nil {
+			w.report.push(pathElem{index: j})
+		}
+		err := w.walkElement(ctx, s.Index(j))
+		if w.report != nil {
+			w.report.pop()
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (w *walker) walkElement(ctx context.Context, sliceField reflect.Value) error {
+	if sliceField.Kind() == reflect.Struct {
+		return w.walkStruct(ctx, sliceField)
+	}
+	if sliceField.Kind() == reflect.Ptr && !sliceField.IsNil() && sliceField.Elem().Kind() == reflect.Struct && w.enter(sliceField) {
+		return w.walkStruct(ctx, sliceField.Elem())
+	}
+	return nil
 }
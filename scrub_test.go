@@ -1,9 +1,15 @@
 package scrub
 
 import (
+	"context"
+	"fmt"
+	"math"
+	"math/rand/v2"
+	"reflect"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestTaggedField(t *testing.T) {
@@ -125,6 +131,34 @@ func TestTaggedField(t *testing.T) {
 		assert.Equal(t, expected, actual)
 	})
 
+	t.Run("with a struct containing a pointer to a struct, scrubs the tagged fields that follow it", func(t *testing.T) {
+		type place struct {
+			Name string `scrub:"true"`
+		}
+		type person struct {
+			Place  *place
+			Name   string `scrub:"true"`
+			Height float64
+		}
+		actual := person{Place: &place{Name: "earth"}, Name: "Testy Tester", Height: 5.8}
+		expected := person{Place: &place{Name: ""}, Name: "", Height: 5.8}
+		TaggedFields(&actual)
+		assert.Equal(t, expected, actual)
+	})
+
+	t.Run("with a struct containing a tagged pointer to a non-struct, scrubs the pointer", func(t *testing.T) {
+		type person struct {
+			Name  string
+			Place *string `scrub:"true"`
+		}
+		place := "earth"
+		actual := person{Name: "Testy Tester", Place: &place}
+		expected := person{Name: "Testy Tester", Place: nil}
+		TaggedFields(&actual)
+		assert.Equal(t, expected, actual)
+		assert.Equal(t, "earth", place)
+	})
+
 	t.Run("with a struct containing a tagged struct, scrubs the tagged fields", func(t *testing.T) {
 		type place struct {
 			Name      string
@@ -772,3 +806,719 @@ func TestNamedFields(t *testing.T) {
 		assert.Equal(t, expected, actual)
 	})
 }
+
+func TestTaggedFieldsContext(t *testing.T) {
+	type place struct {
+		Name      string
+		Latitude  float64 `scrub:"true"`
+		Longitude float64 `scrub:"true"`
+	}
+	type person struct {
+		Name   string `scrub:"true"`
+		Places []place
+		Visits []*place
+	}
+	newPerson := func(n int) *person {
+		p := &person{Name: "Testy Tester"}
+		for i := 0; i < n; i++ {
+			p.Places = append(p.Places, place{Name: "Place", Latitude: float64(i), Longitude: 1.0})
+			if i%3 == 0 {
+				p.Visits = append(p.Visits, nil)
+			} else {
+				p.Visits = append(p.Visits, &place{Name: "Visit", Latitude: float64(i), Longitude: 1.0})
+			}
+		}
+		return p
+	}
+
+	t.Run("with no options, scrubs the tagged fields", func(t *testing.T) {
+		actual := newPerson(10)
+		expected := newPerson(10)
+		TaggedFields(expected)
+
+		err := TaggedFieldsContext(context.Background(), actual)
+		assert.NoError(t, err)
+		assert.Equal(t, expected, actual)
+	})
+
+	t.Run("with a canceled context, returns the context's error", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+
+		actual := newPerson(10)
+		err := TaggedFieldsContext(ctx, actual)
+		assert.ErrorIs(t, err, context.Canceled)
+		assert.Equal(t, newPerson(10), actual)
+	})
+
+	t.Run("with parallelism, scrubs large slices the same as a sequential scrub", func(t *testing.T) {
+		actual := newPerson(10_000)
+		expected := newPerson(10_000)
+		TaggedFields(expected)
+
+		err := TaggedFieldsContext(context.Background(), actual, WithParallelism(4, 100))
+		assert.NoError(t, err)
+		assert.Equal(t, expected, actual)
+	})
+
+	t.Run("with parallelism, scrubs slices below the threshold", func(t *testing.T) {
+		actual := newPerson(10)
+		expected := newPerson(10)
+		TaggedFields(expected)
+
+		err := TaggedFieldsContext(context.Background(), actual, WithParallelism(4, 100))
+		assert.NoError(t, err)
+		assert.Equal(t, expected, actual)
+	})
+
+	t.Run("with parallelism and more workers than elements, scrubs every element", func(t *testing.T) {
+		actual := newPerson(3)
+		expected := newPerson(3)
+		TaggedFields(expected)
+
+		err := TaggedFieldsContext(context.Background(), actual, WithParallelism(16, 1))
+		assert.NoError(t, err)
+		assert.Equal(t, expected, actual)
+	})
+
+	t.Run("with parallelism and a threshold of zero, scrubs empty slices", func(t *testing.T) {
+		actual := &person{Name: "Testy Tester", Places: []place{}, Visits: []*place{}}
+
+		err := TaggedFieldsContext(context.Background(), actual, WithParallelism(4, 0))
+		assert.NoError(t, err)
+		assert.Equal(t, &person{Places: []place{}, Visits: []*place{}}, actual)
+	})
+
+	t.Run("with a context that's canceled during the scrub, returns the context's error", func(t *testing.T) {
+		ctx := &countdownContext{Context: context.Background(), remaining: 1}
+
+		err := TaggedFieldsContext(ctx, newPerson(10_000))
+		assert.ErrorIs(t, err, context.Canceled)
+	})
+
+	t.Run("with a node limit that isn't exceeded, scrubs the tagged fields", func(t *testing.T) {
+		actual := newPerson(2)
+		expected := newPerson(2)
+		TaggedFields(expected)
+
+		// 3 fields on person, 2 elements in each slice, and 3 fields on each of the 3 non-nil places
+		err := TaggedFieldsContext(context.Background(), actual, WithMaxNodes(16))
+		assert.NoError(t, err)
+		assert.Equal(t, expected, actual)
+	})
+
+	t.Run("with a node limit that's exceeded, returns ErrMaxNodes", func(t *testing.T) {
+		err := TaggedFieldsContext(context.Background(), newPerson(2), WithMaxNodes(15))
+		assert.ErrorIs(t, err, ErrMaxNodes)
+		assert.ErrorContains(t, err, "visited more than 15 nodes")
+	})
+
+	t.Run("with parallelism and a node limit that's exceeded, returns ErrMaxNodes", func(t *testing.T) {
+		err := TaggedFieldsContext(context.Background(), newPerson(10_000), WithParallelism(4, 100), WithMaxNodes(1000))
+		assert.ErrorIs(t, err, ErrMaxNodes)
+	})
+
+	t.Run("with a depth limit that's exceeded by cyclic pointers, returns ErrMaxDepth", func(t *testing.T) {
+		type node struct {
+			Name string `scrub:"true"`
+			Next *node
+		}
+		n := &node{Name: "loop"}
+		n.Next = n
+
+		err := TaggedFieldsContext(context.Background(), n, WithMaxDepth(10))
+		assert.ErrorIs(t, err, ErrMaxDepth)
+		assert.ErrorContains(t, err, "nested more than 10 levels deep")
+	})
+
+	t.Run("with cycle detection, scrubs cyclic pointers once", func(t *testing.T) {
+		type node struct {
+			Name     string `scrub:"true"`
+			Next     *node
+			Children []*node
+		}
+		n := &node{Name: "a", Next: &node{Name: "b"}}
+		n.Next.Next = n
+		n.Children = []*node{n, n.Next, {Name: "c", Next: n}}
+
+		err := TaggedFieldsContext(context.Background(), n, WithCycleDetection(), WithMaxDepth(10))
+		assert.NoError(t, err)
+		assert.Equal(t, "", n.Name)
+		assert.Equal(t, "", n.Next.Name)
+		assert.Equal(t, "", n.Children[2].Name)
+	})
+
+	t.Run("with cycle detection and parallelism, scrubs cyclic pointers", func(t *testing.T) {
+		type node struct {
+			Name     string `scrub:"true"`
+			Children []*node
+		}
+		root := &node{Name: "root"}
+		for i := 0; i < 100; i++ {
+			root.Children = append(root.Children, &node{Name: "child", Children: []*node{root}})
+		}
+
+		err := TaggedFieldsContext(context.Background(), root, WithCycleDetection(), WithParallelism(4, 10))
+		assert.NoError(t, err)
+		assert.Equal(t, "", root.Name)
+		for _, child := range root.Children {
+			assert.Equal(t, "", child.Name)
+		}
+	})
+
+	t.Run("with a depth limit that isn't exceeded, scrubs the tagged fields", func(t *testing.T) {
+		type node struct {
+			Name string `scrub:"true"`
+			Next *node
+		}
+		actual := &node{Name: "a", Next: &node{Name: "b", Next: &node{Name: "c"}}}
+		expected := &node{Next: &node{Next: &node{}}}
+
+		err := TaggedFieldsContext(context.Background(), actual, WithMaxDepth(2))
+		assert.NoError(t, err)
+		assert.Equal(t, expected, actual)
+	})
+
+	t.Run("with parallelism and nested slices, scrubs the nested slices", func(t *testing.T) {
+		type group struct {
+			People []person
+		}
+		actual := group{}
+		expected := group{}
+		for i := 0; i < 100; i++ {
+			actual.People = append(actual.People, *newPerson(100))
+			expected.People = append(expected.People, *newPerson(100))
+		}
+		TaggedFields(&expected)
+
+		err := TaggedFieldsContext(context.Background(), &actual, WithParallelism(0, 10))
+		assert.NoError(t, err)
+		assert.Equal(t, expected, actual)
+	})
+}
+
+// countdownContext reports that it's canceled after Err has been called remaining times.
+type countdownContext struct {
+	context.Context
+	remaining int
+}
+
+func (c *countdownContext) Err() error {
+	if c.remaining == 0 {
+		return context.Canceled
+	}
+	c.remaining--
+	return nil
+}
+
+func TestWithUnexportedFields(t *testing.T) {
+	type place struct {
+		name      string `scrub:"true"`
+		Latitude  float64
+		longitude float64 `scrub:"true"`
+	}
+
+	t.Run("without the option, leaves unexported fields unchanged", func(t *testing.T) {
+		actual := place{name: "earth", Latitude: 1.0, longitude: 2.0}
+		expected := place{name: "earth", Latitude: 1.0, longitude: 2.0}
+
+		err := TaggedFieldsContext(context.Background(), &actual)
+		assert.NoError(t, err)
+		assert.Equal(t, expected, actual)
+	})
+
+	t.Run("with unexported scalar fields, scrubs the tagged ones", func(t *testing.T) {
+		actual := place{name: "earth", Latitude: 1.0, longitude: 2.0}
+		expected := place{name: "", Latitude: 1.0, longitude: 0.0}
+
+		err := TaggedFieldsContext(context.Background(), &actual, WithUnexportedFields())
+		assert.NoError(t, err)
+		assert.Equal(t, expected, actual)
+	})
+
+	t.Run("with a struct passed by value, leaves unexported fields unchanged", func(t *testing.T) {
+		type holder struct {
+			place place
+			Place *place
+		}
+		shared := &place{name: "mars", longitude: 3.0}
+
+		err := TaggedFieldsContext(context.Background(), holder{place: place{name: "earth"}, Place: shared}, WithUnexportedFields())
+		assert.NoError(t, err)
+		assert.Equal(t, &place{}, shared, "structs behind pointers can still be scrubbed")
+	})
+
+	t.Run("with unexported scalar fields, scrubs the named ones", func(t *testing.T) {
+		type credentials struct {
+			user     string
+			password string
+		}
+		actual := credentials{user: "testy", password: "hunter2"}
+		expected := credentials{user: "testy", password: ""}
+
+		err := NamedFieldsContext(context.Background(), &actual, []string{"password"}, WithUnexportedFields())
+		assert.NoError(t, err)
+		assert.Equal(t, expected, actual)
+	})
+
+	t.Run("with an unexported pointer to a struct, scrubs the tagged fields of the struct", func(t *testing.T) {
+		type person struct {
+			Name  string
+			place *place
+		}
+		actual := person{Name: "Testy Tester", place: &place{name: "earth", Latitude: 1.0, longitude: 2.0}}
+		expected := person{Name: "Testy Tester", place: &place{name: "", Latitude: 1.0, longitude: 0.0}}
+
+		err := TaggedFieldsContext(context.Background(), &actual, WithUnexportedFields())
+		assert.NoError(t, err)
+		assert.Equal(t, expected, actual)
+	})
+
+	t.Run("with a tagged unexported pointer to a struct, scrubs the pointer", func(t *testing.T) {
+		type person struct {
+			Name  string
+			place *place `scrub:"true"`
+		}
+		actual := person{Name: "Testy Tester", place: &place{name: "earth"}}
+		expected := person{Name: "Testy Tester", place: nil}
+
+		err := TaggedFieldsContext(context.Background(), &actual, WithUnexportedFields())
+		assert.NoError(t, err)
+		assert.Equal(t, expected, actual)
+	})
+
+	t.Run("with unexported slices, scrubs the tagged slices and walks the others", func(t *testing.T) {
+		type person struct {
+			Name    string
+			aliases []string `scrub:"true"`
+			places  []place
+			visits  []*place
+		}
+		actual := person{
+			Name:    "Testy Tester",
+			aliases: []string{"testy"},
+			places:  []place{{name: "earth", Latitude: 1.0, longitude: 2.0}},
+			visits:  []*place{{name: "mars", Latitude: 3.0, longitude: 4.0}, nil},
+		}
+		expected := person{
+			Name:    "Testy Tester",
+			aliases: nil,
+			places:  []place{{name: "", Latitude: 1.0, longitude: 0.0}},
+			visits:  []*place{{name: "", Latitude: 3.0, longitude: 0.0}, nil},
+		}
+
+		err := TaggedFieldsContext(context.Background(), &actual, WithUnexportedFields())
+		assert.NoError(t, err)
+		assert.Equal(t, expected, actual)
+	})
+
+	t.Run("with an embedded unexported struct, scrubs its tagged fields", func(t *testing.T) {
+		type person struct {
+			place
+			Name string `scrub:"true"`
+		}
+		actual := person{place: place{name: "earth", Latitude: 1.0, longitude: 2.0}, Name: "Testy Tester"}
+		expected := person{place: place{name: "", Latitude: 1.0, longitude: 0.0}, Name: ""}
+
+		err := TaggedFieldsContext(context.Background(), &actual, WithUnexportedFields())
+		assert.NoError(t, err)
+		assert.Equal(t, expected, actual)
+	})
+}
+
+func TestNamedFieldsContext(t *testing.T) {
+	type place struct {
+		Name      string
+		Latitude  float64
+		Longitude float64
+	}
+	type person struct {
+		Name   string
+		Places []*place
+	}
+	newPerson := func(n int) *person {
+		p := &person{Name: "Testy Tester"}
+		for i := 0; i < n; i++ {
+			p.Places = append(p.Places, &place{Name: "Place", Latitude: float64(i), Longitude: 1.0})
+		}
+		return p
+	}
+
+	t.Run("with no options, scrubs the named fields", func(t *testing.T) {
+		actual := newPerson(10)
+		expected := newPerson(10)
+		NamedFields(expected, "Latitude", "Longitude")
+
+		err := NamedFieldsContext(context.Background(), actual, []string{"Latitude", "Longitude"})
+		assert.NoError(t, err)
+		assert.Equal(t, expected, actual)
+	})
+
+	t.Run("with a canceled context, returns the context's error", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+
+		actual := newPerson(10)
+		err := NamedFieldsContext(ctx, actual, []string{"Latitude", "Longitude"})
+		assert.ErrorIs(t, err, context.Canceled)
+		assert.Equal(t, newPerson(10), actual)
+	})
+
+	t.Run("with parallelism, scrubs large slices the same as a sequential scrub", func(t *testing.T) {
+		actual := newPerson(10_000)
+		expected := newPerson(10_000)
+		NamedFields(expected, "Latitude", "Longitude")
+
+		err := NamedFieldsContext(context.Background(), actual, []string{"Latitude", "Longitude"}, WithParallelism(4, 100))
+		assert.NoError(t, err)
+		assert.Equal(t, expected, actual)
+	})
+}
+
+type benchAddress struct {
+	Street string `scrub:"true"`
+	City   string
+	Zip    string `scrub:"true"`
+}
+
+type benchUser struct {
+	ID       int
+	Name     string
+	Email    string `scrub:"true"`
+	Password string `scrub:"true"`
+	Age      int
+	Score    float64
+	Active   bool
+	Token    string `scrub:"true"`
+	Address  benchAddress
+	Home     *benchAddress
+}
+
+type benchNode struct {
+	Secret string `scrub:"true"`
+	Value  int
+	Child  *benchNode
+}
+
+type benchBatch struct {
+	Users []benchUser
+}
+
+type benchGraph struct {
+	Secret   string `scrub:"true"`
+	Value    int
+	Children []*benchGraph
+	Owner    *benchUser
+}
+
+var benchNames = []string{"Email", "Password", "Token", "Street", "Zip", "Secret"}
+
+type benchFixture struct {
+	name string
+	new  func() any
+	// allocs is the number of allocations a scrub of the fixture may make. The budgets rely on the escape
+	// analysis of Go 1.24, the minimum in go.mod; earlier toolchains move the walker to the heap.
+	allocs float64
+}
+
+func benchFixtures() []benchFixture {
+	newUser := func(i int) benchUser {
+		return benchUser{
+			ID:       i,
+			Name:     "Testy Tester",
+			Email:    "testy@example.com",
+			Password: "hunter2",
+			Age:      26,
+			Score:    3.14,
+			Active:   true,
+			Token:    "abc123",
+			Address:  benchAddress{Street: "1 Main St", City: "Springfield", Zip: "12345"},
+			Home:     &benchAddress{Street: "2 Main St", City: "Springfield", Zip: "12345"},
+		}
+	}
+
+	var newGraph func(depth int) *benchGraph
+	newGraph = func(depth int) *benchGraph {
+		user := newUser(depth)
+		g := &benchGraph{Secret: "secret", Value: depth, Owner: &user}
+		if depth > 0 {
+			for i := 0; i < 4; i++ {
+				g.Children = append(g.Children, newGraph(depth-1))
+			}
+		}
+		return g
+	}
+
+	return []benchFixture{
+		{
+			name: "flat",
+			new: func() any {
+				u := newUser(1)
+				return &u
+			},
+		},
+		{
+			name: "deep nesting",
+			new: func() any {
+				root := &benchNode{Secret: "secret"}
+				node := root
+				for i := 0; i < 100; i++ {
+					node.Child = &benchNode{Secret: "secret", Value: i}
+					node = node.Child
+				}
+				return root
+			},
+		},
+		{
+			name: "large slice of structs",
+			new: func() any {
+				batch := &benchBatch{Users: make([]benchUser, 10_000)}
+				for i := range batch.Users {
+					batch.Users[i] = newUser(i)
+				}
+				return batch
+			},
+		},
+		{
+			name: "pointer-heavy graph",
+			new: func() any {
+				return newGraph(5)
+			},
+		},
+	}
+}
+
+func BenchmarkTaggedFields(b *testing.B) {
+	for _, fixture := range benchFixtures() {
+		b.Run(fixture.name, func(b *testing.B) {
+			v := fixture.new()
+			b.ReportAllocs()
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				TaggedFields(v)
+			}
+		})
+	}
+}
+
+func BenchmarkNamedFields(b *testing.B) {
+	for _, fixture := range benchFixtures() {
+		b.Run(fixture.name, func(b *testing.B) {
+			v := fixture.new()
+			b.ReportAllocs()
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				NamedFields(v, benchNames...)
+			}
+		})
+	}
+}
+
+func BenchmarkTaggedFieldsContext(b *testing.B) {
+	for _, fixture := range benchFixtures() {
+		b.Run(fixture.name, func(b *testing.B) {
+			v := fixture.new()
+			b.ReportAllocs()
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				_ = TaggedFieldsContext(context.Background(), v, WithParallelism(0, 1000))
+			}
+		})
+	}
+}
+
+func TestAllocations(t *testing.T) {
+	for _, fixture := range benchFixtures() {
+		t.Run(fixture.name, func(t *testing.T) {
+			t.Run("TaggedFields stays within its allocation budget", func(t *testing.T) {
+				v := fixture.new()
+				allocs := testing.AllocsPerRun(10, func() {
+					TaggedFields(v)
+				})
+				assert.LessOrEqual(t, allocs, fixture.allocs)
+			})
+
+			t.Run("NamedFields stays within its allocation budget", func(t *testing.T) {
+				v := fixture.new()
+				allocs := testing.AllocsPerRun(10, func() {
+					NamedFields(v, "Email", "Password", "Token", "Street", "Zip", "Secret")
+				})
+				assert.LessOrEqual(t, allocs, fixture.allocs)
+			})
+		})
+	}
+}
+
+// shapeGenerator builds random struct types with reflect.StructOf, and random values of them, for property
+// and fuzz tests. The types nest pointers, slices, maps, arrays and interfaces, and their fields are named
+// F0 to F3 and tagged `scrub:"true"` at random.
+type shapeGenerator struct {
+	r *rand.Rand
+}
+
+var anyType = reflect.TypeOf((*any)(nil)).Elem()
+
+func (g shapeGenerator) structType(depth int) reflect.Type {
+	fields := make([]reflect.StructField, 1+g.r.IntN(4))
+	for i := range fields {
+		fields[i] = reflect.StructField{Name: fmt.Sprintf("F%d", i), Type: g.fieldType(depth - 1)}
+		if g.r.IntN(3) == 0 {
+			fields[i].Tag = `scrub:"true"`
+		}
+	}
+	return reflect.StructOf(fields)
+}
+
+func (g shapeGenerator) fieldType(depth int) reflect.Type {
+	if depth <= 0 {
+		return []reflect.Type{reflect.TypeOf(""), reflect.TypeOf(0)}[g.r.IntN(2)]
+	}
+	switch g.r.IntN(9) {
+	case 0:
+		return reflect.TypeOf("")
+	case 1:
+		return reflect.TypeOf(0)
+	case 2:
+		return reflect.PointerTo(g.fieldType(depth - 1))
+	case 3:
+		return reflect.SliceOf(g.fieldType(depth - 1))
+	case 4:
+		return reflect.MapOf(reflect.TypeOf(""), g.fieldType(depth-1))
+	case 5:
+		return reflect.ArrayOf(2, g.fieldType(depth-1))
+	case 6:
+		return anyType
+	default:
+		return g.structType(depth)
+	}
+}
+
+// value returns a random value of t. Pointers and slices are sometimes nil, and no two pointers in the value
+// point to the same place.
+func (g shapeGenerator) value(t reflect.Type, depth int) reflect.Value {
+	v := reflect.New(t).Elem()
+	switch t.Kind() {
+	case reflect.String:
+		v.SetString(fmt.Sprintf("s%d", g.r.IntN(1000)))
+	case reflect.Int:
+		v.SetInt(int64(g.r.IntN(1000)))
+	case reflect.Ptr:
+		if g.r.IntN(5) > 0 {
+			p := reflect.New(t.Elem())
+			p.Elem().Set(g.value(t.Elem(), depth))
+			v.Set(p)
+		}
+	case reflect.Slice:
+		if n := g.r.IntN(4); n > 0 {
+			v.Set(reflect.MakeSlice(t, n, n))
+			for i := 0; i < n; i++ {
+				v.Index(i).Set(g.value(t.Elem(), depth))
+			}
+		}
+	case reflect.Map:
+		v.Set(reflect.MakeMap(t))
+		for i := g.r.IntN(3); i > 0; i-- {
+			v.SetMapIndex(reflect.ValueOf(fmt.Sprintf("k%d", i)), g.value(t.Elem(), depth))
+		}
+	case reflect.Array:
+		for i := 0; i < t.Len(); i++ {
+			v.Index(i).Set(g.value(t.Elem(), depth))
+		}
+	case reflect.Interface:
+		if depth > 0 && g.r.IntN(4) > 0 {
+			st := g.structType(depth - 1)
+			if g.r.IntN(2) == 0 {
+				v.Set(g.value(st, depth-1))
+			} else {
+				v.Set(g.value(reflect.PointerTo(st), depth-1))
+			}
+		}
+	case reflect.Struct:
+		for i := 0; i < t.NumField(); i++ {
+			v.Field(i).Set(g.value(t.Field(i).Type, depth))
+		}
+	}
+	return v
+}
+
+// modelScrub is a reference implementation of the walker's traversal: it zeroes the selected fields of the
+// struct v, and of the structs reachable from it through struct fields, pointers to structs, and slices of
+// structs or pointers to structs. Maps, arrays and interfaces aren't scrubbed.
+func modelScrub(v reflect.Value, selected func(reflect.StructField) bool) {
+	for i := 0; i < v.NumField(); i++ {
+		field := v.Field(i)
+		if selected(v.Type().Field(i)) {
+			field.SetZero()
+			continue
+		}
+		switch field.Kind() {
+		case reflect.Struct:
+			modelScrub(field, selected)
+		case reflect.Ptr:
+			if !field.IsNil() && field.Elem().Kind() == reflect.Struct {
+				modelScrub(field.Elem(), selected)
+			}
+		case reflect.Slice:
+			for j := 0; j < field.Len(); j++ {
+				elem := field.Index(j)
+				if elem.Kind() == reflect.Ptr && !elem.IsNil() {
+					elem = elem.Elem()
+				}
+				if elem.Kind() == reflect.Struct {
+					modelScrub(elem, selected)
+				}
+			}
+		}
+	}
+}
+
+// checkShape scrubs a random value of a random struct type, generated from seed, and checks that the
+// result matches modelScrub: the selected fields that the walker reaches are zero, and everything else is
+// unchanged. It checks sequential and parallel scrubs, with tags and with names.
+func checkShape(t *testing.T, seed uint64) {
+	g := shapeGenerator{r: rand.New(rand.NewPCG(seed, seed>>32))}
+	typ := g.structType(4)
+	original := g.value(typ, 4).Addr().Interface()
+
+	tagged := func(f reflect.StructField) bool { return f.Tag.Get("scrub") == "true" }
+	named := func(f reflect.StructField) bool { return f.Name == "F0" || f.Name == "F2" }
+	for _, c := range []struct {
+		name     string
+		selected func(reflect.StructField) bool
+		scrub    func(v any, opts ...Option) error
+	}{
+		{"TaggedFields", tagged, func(v any, opts ...Option) error {
+			return TaggedFieldsContext(context.Background(), v, opts...)
+		}},
+		{"NamedFields", named, func(v any, opts ...Option) error {
+			return NamedFieldsContext(context.Background(), v, []string{"F0", "F2"}, opts...)
+		}},
+	} {
+		expected := Copy(original)
+		modelScrub(reflect.ValueOf(expected).Elem(), c.selected)
+
+		for _, opts := range [][]Option{nil, {WithParallelism(2, 1)}} {
+			actual := Copy(original)
+			require.NoError(t, c.scrub(actual, opts...), "%s of %s", c.name, typ)
+			require.True(t, reflect.DeepEqual(expected, actual), "%s of %s with %d options:\nexpected %+v\nactual   %+v",
+				c.name, typ, len(opts), reflect.ValueOf(expected).Elem(), reflect.ValueOf(actual).Elem())
+		}
+	}
+}
+
+func TestShapeProperties(t *testing.T) {
+	t.Run("with random struct shapes, scrubs exactly the selected fields the walker reaches", func(t *testing.T) {
+		for seed := uint64(0); seed < 500; seed++ {
+			checkShape(t, seed)
+		}
+	})
+}
+
+func FuzzShapes(f *testing.F) {
+	for _, seed := range []uint64{0, 1, 42, 1 << 40, math.MaxUint64} {
+		f.Add(seed)
+	}
+	f.Fuzz(func(t *testing.T, seed uint64) {
+		checkShape(t, seed)
+	})
+}
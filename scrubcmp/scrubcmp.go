@@ -0,0 +1,79 @@
+// Package scrubcmp compares values with github.com/google/go-cmp while ignoring the fields that scrub would
+// scrub, such as timestamps, random IDs and secrets, without copying or modifying either value.
+//
+// Fields annotated with a `scrub:"true"` struct tag are always ignored; fields with any of the names passed
+// to IgnoreFields or Equal are ignored as well. As with scrub.TaggedFields and scrub.NamedFields, an
+// ignored struct field is ignored along with everything inside it, and fields are only ignored where scrub
+// reaches them: through struct fields, pointers to structs and slices of either, but not through maps,
+// arrays or interfaces.
+package scrubcmp
+
+import (
+	"reflect"
+	"slices"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/stretchr/testify/assert"
+)
+
+// IgnoreFields returns a cmp.Option that ignores struct fields annotated with a `scrub:"true"` struct tag
+// and fields with any of the given names.
+func IgnoreFields(names ...string) cmp.Option {
+	return cmp.FilterPath(func(p cmp.Path) bool {
+		return selected(p, names)
+	}, cmp.Ignore())
+}
+
+// selected reports whether the last step of p is a struct field that scrub would scrub: a field selected by
+// its tag or name, reached only through the steps that scrub follows.
+func selected(p cmp.Path, names []string) bool {
+	step, ok := p.Last().(cmp.StructField)
+	if !ok || !followed(p) {
+		return false
+	}
+	field := p.Index(-2).Type().Field(step.Index())
+	return field.Tag.Get("scrub") == "true" || slices.Contains(names, field.Name)
+}
+
+// followed reports whether scrub would walk down to the last step of p. It follows struct fields, pointers
+// to structs and slices of either in struct fields, but not maps, arrays or interfaces.
+func followed(p cmp.Path) bool {
+	for i := 1; i < len(p); i++ {
+		switch p[i].(type) {
+		case cmp.StructField:
+		case cmp.Indirect:
+			if p[i].Type().Kind() != reflect.Struct {
+				return false
+			}
+		case cmp.SliceIndex:
+			if _, ok := p[i-1].(cmp.StructField); !ok || p[i-1].Type().Kind() != reflect.Slice {
+				return false
+			}
+			elem := p[i].Type()
+			if elem.Kind() == reflect.Ptr {
+				elem = elem.Elem()
+			}
+			if elem.Kind() != reflect.Struct {
+				return false
+			}
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+// Equal asserts that expected and actual are equal according to cmp.Equal, ignoring the fields selected as
+// by IgnoreFields. It reports a failure to t with a diff, in the style of the testify assert package, and
+// returns whether the assertion succeeded. For more control, such as comparing unexported fields, use
+// cmp.Diff with IgnoreFields and other options.
+func Equal(t assert.TestingT, expected, actual any, names ...string) bool {
+	if h, ok := t.(interface{ Helper() }); ok {
+		h.Helper()
+	}
+	diff := cmp.Diff(expected, actual, IgnoreFields(names...))
+	if diff == "" {
+		return true
+	}
+	return assert.Fail(t, "Not equal, ignoring scrubbed fields (-expected +actual):\n"+diff)
+}
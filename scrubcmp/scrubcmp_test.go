@@ -0,0 +1,146 @@
+package scrubcmp
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/stretchr/testify/assert"
+)
+
+// recordingT records failures reported by Equal, which needs a package-level type for its method.
+type recordingT struct {
+	errors []string
+}
+
+func (t *recordingT) Errorf(format string, args ...any) {
+	t.errors = append(t.errors, fmt.Sprintf(format, args...))
+}
+
+func TestIgnoreFields(t *testing.T) {
+	type actor struct {
+		Name    string
+		Session string
+	}
+	type event struct {
+		Name      string
+		ID        string    `scrub:"true"`
+		CreatedAt time.Time `scrub:"true"`
+		Actor     *actor
+		Targets   []actor
+	}
+	newEvent := func(id, session string) event {
+		return event{
+			Name:      "login",
+			ID:        id,
+			CreatedAt: time.Unix(int64(len(id)), 0),
+			Actor:     &actor{Name: "Testy Tester", Session: session},
+			Targets:   []actor{{Name: "Friendly Tester", Session: session}},
+		}
+	}
+
+	t.Run("with tagged fields, ignores them", func(t *testing.T) {
+		a, b := newEvent("evt_1", "s1"), newEvent("evt_22", "s1")
+
+		assert.True(t, cmp.Equal(a, b, IgnoreFields()))
+		assert.False(t, cmp.Equal(a, b))
+	})
+
+	t.Run("with names, ignores the named fields wherever they are", func(t *testing.T) {
+		a, b := newEvent("evt_1", "s1"), newEvent("evt_1", "s2")
+
+		assert.False(t, cmp.Equal(a, b, IgnoreFields()))
+		assert.True(t, cmp.Equal(a, b, IgnoreFields("Session")))
+	})
+
+	t.Run("with other differences, still reports them", func(t *testing.T) {
+		a, b := newEvent("evt_1", "s1"), newEvent("evt_22", "s2")
+		b.Actor.Name = "Imposter"
+
+		diff := cmp.Diff(a, b, IgnoreFields("Session"))
+
+		assert.Contains(t, diff, "Imposter")
+		assert.NotContains(t, diff, "evt_")
+		assert.NotContains(t, diff, "s2")
+	})
+
+	t.Run("with a named struct field, ignores everything inside it", func(t *testing.T) {
+		a, b := newEvent("evt_1", "s1"), newEvent("evt_1", "s1")
+		b.Actor = &actor{Name: "Imposter"}
+
+		assert.True(t, cmp.Equal(a, b, IgnoreFields("Actor")))
+	})
+
+	t.Run("with fields that scrub doesn't reach, compares them", func(t *testing.T) {
+		type session struct {
+			Token string `scrub:"true"`
+		}
+		type account struct {
+			ByName   map[string]session
+			Pair     [1]session
+			Current  any
+			Sessions []session
+		}
+		newAccount := func(token string) account {
+			return account{
+				ByName:   map[string]session{"web": {Token: token}},
+				Pair:     [1]session{{Token: "t"}},
+				Current:  session{Token: "t"},
+				Sessions: []session{{Token: token}},
+			}
+		}
+		a, b := newAccount("t1"), newAccount("t2")
+
+		assert.False(t, cmp.Equal(a, b, IgnoreFields()))
+		assert.Contains(t, cmp.Diff(a, b, IgnoreFields()), "ByName")
+		assert.NotContains(t, cmp.Diff(a, b, IgnoreFields()), "Sessions")
+		b.ByName["web"] = session{Token: "t1"}
+		b.Pair[0].Token = "t2"
+		assert.False(t, cmp.Equal(a, b, IgnoreFields()))
+		b.Pair[0].Token = "t"
+		b.Current = session{Token: "t2"}
+		assert.False(t, cmp.Equal(a, b, IgnoreFields()))
+		b.Current = session{Token: "t"}
+		assert.True(t, cmp.Equal(a, b, IgnoreFields()))
+	})
+
+	t.Run("with either value, doesn't modify it", func(t *testing.T) {
+		a, b := newEvent("evt_1", "s1"), newEvent("evt_22", "s2")
+
+		cmp.Equal(a, b, IgnoreFields("Session"))
+
+		assert.Equal(t, newEvent("evt_1", "s1"), a)
+		assert.Equal(t, newEvent("evt_22", "s2"), b)
+	})
+}
+
+func TestEqual(t *testing.T) {
+	type event struct {
+		Name    string
+		ID      string `scrub:"true"`
+		Session string
+	}
+
+	t.Run("with values that differ only in ignored fields, succeeds", func(t *testing.T) {
+		rt := &recordingT{}
+		a := event{Name: "login", ID: "evt_1", Session: "s1"}
+		b := event{Name: "login", ID: "evt_22", Session: "s2"}
+
+		assert.True(t, Equal(rt, a, b, "Session"))
+		assert.Empty(t, rt.errors)
+	})
+
+	t.Run("with values that differ in other fields, fails with a diff", func(t *testing.T) {
+		rt := &recordingT{}
+		a := event{Name: "login", ID: "evt_1", Session: "s1"}
+		b := event{Name: "logout", ID: "evt_22", Session: "s1"}
+
+		assert.False(t, Equal(rt, a, b))
+		if assert.Len(t, rt.errors, 1) {
+			assert.Contains(t, rt.errors[0], "Not equal, ignoring scrubbed fields")
+			assert.Contains(t, rt.errors[0], `"logout"`)
+			assert.NotContains(t, rt.errors[0], "evt_")
+		}
+	})
+}
@@ -0,0 +1,180 @@
+// Package scrubcsv scrubs the columns of CSV data, such as exports to partners, as it's read or written.
+//
+// The first record of the data is its header. Columns are selected by matching their header names against
+// rules, in the same way NamedFields matches field names, and the values in each selected column are
+// zeroed, masked, hashed or faked. Columns selected with scrub.Omit are removed. Records are processed one
+// at a time, so data of any size can be scrubbed. Quoted fields are handled by encoding/csv.
+package scrubcsv
+
+import (
+	"encoding/csv"
+	"errors"
+	"io"
+
+	"github.com/acj/scrub"
+)
+
+// Option configures a Reader, a Writer or Scrub.
+type Option func(*options)
+
+type options struct {
+	rules []scrub.Rule
+	comma rune
+}
+
+// WithRules scrubs the columns whose header names are selected by rules. A rule's name, glob and path are
+// all matched against the header name. When more than one rule matches, the first one wins.
+func WithRules(rules ...scrub.Rule) Option {
+	return func(o *options) {
+		o.rules = append(o.rules, rules...)
+	}
+}
+
+// WithNames zeroes the columns with the given header names. It's shorthand for WithRules with a Name rule
+// for each name.
+func WithNames(names ...string) Option {
+	return func(o *options) {
+		for _, name := range names {
+			o.rules = append(o.rules, scrub.Rule{Name: name})
+		}
+	}
+}
+
+// WithComma sets the field delimiter, which defaults to ','. See csv.Reader.Comma for the restrictions on
+// it.
+func WithComma(r rune) Option {
+	return func(o *options) {
+		o.comma = r
+	}
+}
+
+func newOptions(opts []Option) options {
+	o := options{comma: ','}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	return o
+}
+
+// columns holds the action for each column, or "" for columns that are left alone.
+type columns []scrub.Action
+
+func selectColumns(header []string, rules []scrub.Rule) columns {
+	c := make(columns, len(header))
+	for i, name := range header {
+		for _, r := range rules {
+			if r.Matches(name, []string{name}) {
+				c[i] = r.ActionOrDefault()
+				break
+			}
+		}
+	}
+	return c
+}
+
+// apply scrubs record in place, removing omitted columns, and returns the result. A header is scrubbed by
+// removing omitted columns only.
+func (c columns) apply(record []string, header bool) []string {
+	out := record[:0]
+	for i, value := range record {
+		var a scrub.Action
+		if i < len(c) {
+			a = c[i]
+		}
+		switch {
+		case a == scrub.Omit:
+			continue
+		case a != "" && !header:
+			value = a.Replace(value)
+		}
+		out = append(out, value)
+	}
+	return out
+}
+
+// Reader reads scrubbed records from CSV data.
+type Reader struct {
+	r       *csv.Reader
+	rules   []scrub.Rule
+	columns columns
+}
+
+// NewReader returns a Reader that reads from r. The first record it returns is the header, without any
+// omitted columns.
+func NewReader(r io.Reader, opts ...Option) *Reader {
+	o := newOptions(opts)
+	cr := csv.NewReader(r)
+	cr.Comma = o.comma
+	return &Reader{r: cr, rules: o.rules}
+}
+
+// Read reads and scrubs one record. It returns the same errors as csv.Reader.Read, including io.EOF at the
+// end of the data.
+func (r *Reader) Read() ([]string, error) {
+	record, err := r.r.Read()
+	if err != nil {
+		return nil, err
+	}
+	header := r.columns == nil
+	if header {
+		r.columns = selectColumns(record, r.rules)
+	}
+	return r.columns.apply(record, header), nil
+}
+
+// Writer writes scrubbed records as CSV data.
+type Writer struct {
+	w       *csv.Writer
+	rules   []scrub.Rule
+	columns columns
+}
+
+// NewWriter returns a Writer that writes to w. The first record written to it must be the header.
+func NewWriter(w io.Writer, opts ...Option) *Writer {
+	o := newOptions(opts)
+	cw := csv.NewWriter(w)
+	cw.Comma = o.comma
+	return &Writer{w: cw, rules: o.rules}
+}
+
+// Write scrubs a record and writes it. Like csv.Writer.Write, it buffers the output, so call Flush when
+// done. record itself isn't modified.
+func (w *Writer) Write(record []string) error {
+	header := w.columns == nil
+	if header {
+		w.columns = selectColumns(record, w.rules)
+	}
+	return w.w.Write(w.columns.apply(append([]string(nil), record...), header))
+}
+
+// Flush writes any buffered data to the underlying io.Writer. Call Error to check whether it succeeded.
+func (w *Writer) Flush() {
+	w.w.Flush()
+}
+
+// Error reports any error that has occurred during a previous Write or Flush.
+func (w *Writer) Error() error {
+	return w.w.Error()
+}
+
+// Scrub copies the CSV data read from src to dst, scrubbing it as it goes. Both use the same delimiter.
+func Scrub(dst io.Writer, src io.Reader, opts ...Option) error {
+	r := NewReader(src, opts...)
+	o := newOptions(opts)
+	w := csv.NewWriter(dst)
+	w.Comma = o.comma
+	for {
+		record, err := r.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return err
+		}
+		if err := w.Write(record); err != nil {
+			return err
+		}
+	}
+	w.Flush()
+	return w.Error()
+}
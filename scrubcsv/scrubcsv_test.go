@@ -0,0 +1,108 @@
+package scrubcsv
+
+import (
+	"bytes"
+	"encoding/csv"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/acj/scrub"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const export = `id,name,email,ssn,notes
+1,Testy Tester,testy@example.com,123-45-6789,"likes ""quotes"", commas"
+2,"Multi
+Line",multi@example.com,987-65-4321,
+`
+
+var rules = []scrub.Rule{
+	{Name: "email", Action: scrub.Hash},
+	{Name: "ssn", Action: scrub.Mask},
+	{Glob: "not*", Action: scrub.Omit},
+	{Name: "name", Action: scrub.Fake},
+}
+
+func TestScrub(t *testing.T) {
+	t.Run("with rules, scrubs the selected columns", func(t *testing.T) {
+		var out bytes.Buffer
+		require.NoError(t, Scrub(&out, strings.NewReader(export), WithRules(rules...)))
+
+		assert.Equal(t, "id,name,email,ssn\n"+
+			"1,"+scrub.Fake.Replace("Testy Tester")+","+scrub.Hash.Replace("testy@example.com")+",****\n"+
+			"2,\""+scrub.Fake.Replace("Multi\nLine")+"\","+scrub.Hash.Replace("multi@example.com")+",****\n",
+			out.String())
+	})
+
+	t.Run("with names, zeroes the named columns", func(t *testing.T) {
+		var out bytes.Buffer
+		require.NoError(t, Scrub(&out, strings.NewReader(export), WithNames("name", "notes")))
+
+		assert.Equal(t, "id,name,email,ssn,notes\n"+
+			"1,,testy@example.com,123-45-6789,\n"+
+			"2,,multi@example.com,987-65-4321,\n",
+			out.String())
+	})
+
+	t.Run("with a delimiter, uses it for reading and writing", func(t *testing.T) {
+		var out bytes.Buffer
+		in := "id;email\n1;\"a;b@example.com\"\n"
+		require.NoError(t, Scrub(&out, strings.NewReader(in), WithComma(';'), WithRules(scrub.Rule{Name: "email", Action: scrub.Mask})))
+
+		assert.Equal(t, "id;email\n1;****\n", out.String())
+	})
+
+	t.Run("with empty input, writes nothing", func(t *testing.T) {
+		var out bytes.Buffer
+		require.NoError(t, Scrub(&out, strings.NewReader(""), WithRules(rules...)))
+		assert.Empty(t, out.String())
+	})
+
+	t.Run("with a malformed record, returns an error", func(t *testing.T) {
+		var out bytes.Buffer
+		err := Scrub(&out, strings.NewReader("id,name\n1,\"unterminated\n"), WithRules(rules...))
+		var parseErr *csv.ParseError
+		assert.ErrorAs(t, err, &parseErr)
+	})
+}
+
+func TestReader(t *testing.T) {
+	t.Run("with rules, returns the header and then scrubbed records", func(t *testing.T) {
+		r := NewReader(strings.NewReader(export), WithRules(scrub.Rule{Name: "ssn"}, scrub.Rule{Name: "id", Action: scrub.Omit}))
+
+		var records [][]string
+		for {
+			record, err := r.Read()
+			if errors.Is(err, io.EOF) {
+				break
+			}
+			require.NoError(t, err)
+			records = append(records, record)
+		}
+
+		assert.Equal(t, [][]string{
+			{"name", "email", "ssn", "notes"},
+			{"Testy Tester", "testy@example.com", "", `likes "quotes", commas`},
+			{"Multi\nLine", "multi@example.com", "", ""},
+		}, records)
+	})
+}
+
+func TestWriter(t *testing.T) {
+	t.Run("with rules, writes the header and then scrubbed records", func(t *testing.T) {
+		var out bytes.Buffer
+		w := NewWriter(&out, WithRules(scrub.Rule{Name: "email", Action: scrub.Mask}), WithComma('\t'))
+		record := []string{"1", "testy@example.com"}
+
+		require.NoError(t, w.Write([]string{"id", "email"}))
+		require.NoError(t, w.Write(record))
+		w.Flush()
+
+		require.NoError(t, w.Error())
+		assert.Equal(t, "id\temail\n1\t****\n", out.String())
+		assert.Equal(t, []string{"1", "testy@example.com"}, record)
+	})
+}
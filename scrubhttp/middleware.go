@@ -0,0 +1,80 @@
+package scrubhttp
+
+import (
+	"log/slog"
+	"net/http"
+	"time"
+)
+
+// Middleware returns middleware that logs every request and its response to logger, with the same
+// scrubbing as Request and Header, after the request has been handled. Each record has the message
+// "http request", a "request" group with the method, URL and headers, a "response" group with the status,
+// the number of body bytes written and the headers, and the duration. Bodies aren't logged. If logger is
+// nil, slog.Default is used.
+func Middleware(logger *slog.Logger, opts ...Option) func(http.Handler) http.Handler {
+	o := newOptions(opts)
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			// Scrub the request before it's handled, since handlers may change it.
+			req := o.request(r)
+			rec := &recorder{ResponseWriter: w}
+			start := time.Now()
+			next.ServeHTTP(rec, r)
+
+			l := logger
+			if l == nil {
+				l = slog.Default()
+			}
+			l.LogAttrs(r.Context(), slog.LevelInfo, "http request",
+				slog.Group("request",
+					slog.String("method", req.Method),
+					slog.String("url", req.URL.String()),
+					slog.Any("header", req.Header),
+				),
+				slog.Group("response",
+					slog.Int("status", rec.status()),
+					slog.Int64("bytes", rec.bytes),
+					slog.Any("header", o.header(rec.Header())),
+				),
+				slog.Duration("duration", time.Since(start)),
+			)
+		})
+	}
+}
+
+// recorder is an http.ResponseWriter that records the status and the number of bytes written. Unwrap lets
+// http.ResponseController reach the features of the underlying writer, such as flushing.
+type recorder struct {
+	http.ResponseWriter
+	code  int
+	bytes int64
+}
+
+func (r *recorder) WriteHeader(code int) {
+	// Informational statuses, such as 103 Early Hints, may come before the final one.
+	if r.code == 0 && code >= 200 {
+		r.code = code
+	}
+	r.ResponseWriter.WriteHeader(code)
+}
+
+func (r *recorder) Write(b []byte) (int, error) {
+	if r.code == 0 {
+		r.code = http.StatusOK
+	}
+	n, err := r.ResponseWriter.Write(b)
+	r.bytes += int64(n)
+	return n, err
+}
+
+func (r *recorder) Unwrap() http.ResponseWriter {
+	return r.ResponseWriter
+}
+
+// status returns the status written, or 200 if the handler didn't write one, as net/http does.
+func (r *recorder) status() int {
+	if r.code == 0 {
+		return http.StatusOK
+	}
+	return r.code
+}
@@ -0,0 +1,85 @@
+package scrubhttp
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/acj/scrub"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMiddleware(t *testing.T) {
+	// newLogger returns a logger that writes JSON lines to buf, without times or durations.
+	newLogger := func(buf *bytes.Buffer) *slog.Logger {
+		return slog.New(slog.NewJSONHandler(buf, &slog.HandlerOptions{
+			ReplaceAttr: func(groups []string, a slog.Attr) slog.Attr {
+				if len(groups) == 0 && (a.Key == slog.TimeKey || a.Key == "duration") {
+					return slog.Attr{}
+				}
+				return a
+			},
+		}))
+	}
+
+	t.Run("with a request, logs scrubbed copies of it and its response", func(t *testing.T) {
+		var buf bytes.Buffer
+		handler := Middleware(newLogger(&buf), WithNames("X-Tenant"))(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			assert.Equal(t, "Bearer abc123", r.Header.Get("Authorization"), "the handler sees the original request")
+			http.SetCookie(w, &http.Cookie{Name: "session", Value: "s3cr3t"})
+			w.WriteHeader(http.StatusCreated)
+			_, _ = io.WriteString(w, "created")
+		}))
+		r := httptest.NewRequest(http.MethodPost, "/orders?token=abc", nil)
+		r.Header.Set("Authorization", "Bearer abc123")
+		r.Header.Set("X-Tenant", "acme")
+		w := httptest.NewRecorder()
+
+		handler.ServeHTTP(w, r)
+
+		var line map[string]any
+		require.NoError(t, json.Unmarshal(buf.Bytes(), &line))
+		assert.Equal(t, map[string]any{
+			"level": "INFO",
+			"msg":   "http request",
+			"request": map[string]any{
+				"method": "POST",
+				"url":    "/orders?token=****",
+				"header": map[string]any{"Authorization": []any{"****"}, "X-Tenant": []any{""}},
+			},
+			"response": map[string]any{
+				"status": float64(http.StatusCreated),
+				"bytes":  float64(len("created")),
+				"header": map[string]any{"Set-Cookie": []any{"****"}},
+			},
+		}, line)
+		assert.Equal(t, "session=s3cr3t", w.Header().Get("Set-Cookie"), "the client gets the original response")
+		assert.Equal(t, "created", w.Body.String())
+	})
+
+	t.Run("with a handler that writes no status, logs 200", func(t *testing.T) {
+		var buf bytes.Buffer
+		handler := Middleware(newLogger(&buf))(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+
+		handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+
+		assert.Contains(t, buf.String(), `"status":200`)
+	})
+
+	t.Run("with a flushing handler, reaches the underlying writer", func(t *testing.T) {
+		var buf bytes.Buffer
+		handler := Middleware(newLogger(&buf), WithRules(scrub.Rule{Glob: "*", Action: scrub.Omit}))(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			assert.NoError(t, http.NewResponseController(w).Flush())
+		}))
+		w := httptest.NewRecorder()
+
+		handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+
+		assert.True(t, w.Flushed)
+	})
+}
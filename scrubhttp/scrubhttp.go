@@ -0,0 +1,235 @@
+// Package scrubhttp scrubs net/http headers, cookies and query values, so that requests and responses can
+// be logged safely.
+//
+// Headers, cookies and query parameters are selected by matching their names against rules, in the same way
+// NamedFields matches field names, except that names are compared case-insensitively, as HTTP does. The
+// values of each selected entry are zeroed, masked, hashed or faked, and entries selected with scrub.Omit are
+// removed. Unless a rule says otherwise, the headers and cookies in DefaultNames and the query parameters
+// that scrub.URL removes are masked. Headers holding URLs, Referer and Location, have their query and
+// fragment parameters scrubbed, as URL does. Every function returns a scrubbed copy and leaves its argument
+// unchanged.
+package scrubhttp
+
+import (
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/acj/scrub"
+)
+
+// defaultNames are the headers and cookies that are masked unless a rule says otherwise.
+var defaultNames = []string{
+	"Authorization", "Proxy-Authorization", "Cookie", "Set-Cookie", "X-Api-Key", "X-Auth-Token",
+	"X-Csrf-Token", "X-Amz-Security-Token",
+}
+
+// urlHeaders are the headers whose values are URLs, scrubbed as URL does unless a rule selects them.
+var urlHeaders = []string{"Referer", "Location"}
+
+// DefaultNames returns the names of the headers and cookies that are masked unless a rule says otherwise.
+func DefaultNames() []string {
+	return append([]string(nil), defaultNames...)
+}
+
+// Option configures the functions in this package and Middleware.
+type Option func(*options)
+
+type options struct {
+	rules []scrub.Rule
+}
+
+// WithRules scrubs the headers, cookies and query parameters whose names are selected by rules. A rule's
+// name, glob and path are all matched against the name, ignoring case. When more than one rule matches,
+// the first one wins, and rules come before the defaults, so they can change what's done with a default
+// name.
+func WithRules(rules ...scrub.Rule) Option {
+	return func(o *options) {
+		o.rules = append(o.rules, rules...)
+	}
+}
+
+// WithNames zeroes the headers, cookies and query parameters with the given names. It's shorthand for
+// WithRules with a Name rule for each name.
+func WithNames(names ...string) Option {
+	return func(o *options) {
+		for _, name := range names {
+			o.rules = append(o.rules, scrub.Rule{Name: name})
+		}
+	}
+}
+
+func newOptions(opts []Option) *options {
+	o := &options{}
+	for _, opt := range opts {
+		opt(o)
+	}
+	for _, names := range [][]string{defaultNames, scrub.URLParams()} {
+		for _, name := range names {
+			o.rules = append(o.rules, scrub.Rule{Name: name, Action: scrub.Mask})
+		}
+	}
+	// Lowercase the rules once, so that names only need to be lowercased when they're matched.
+	for i, r := range o.rules {
+		r.Name, r.Glob, r.Path = strings.ToLower(r.Name), strings.ToLower(r.Glob), strings.ToLower(r.Path)
+		o.rules[i] = r
+	}
+	return o
+}
+
+// action returns the action for the entry with the given name, if it's selected for scrubbing.
+func (o *options) action(name string) (scrub.Action, bool) {
+	name = strings.ToLower(name)
+	for _, r := range o.rules {
+		if r.Matches(name, []string{name}) {
+			return r.ActionOrDefault(), true
+		}
+	}
+	return "", false
+}
+
+// Header returns a scrubbed copy of h.
+func Header(h http.Header, opts ...Option) http.Header {
+	return newOptions(opts).header(h)
+}
+
+func (o *options) header(h http.Header) http.Header {
+	scrubbed := http.Header(o.values(h))
+	for _, name := range urlHeaders {
+		if _, ok := o.action(name); ok {
+			continue
+		}
+		// The values were copied, so they can be replaced in place.
+		for i, value := range scrubbed[name] {
+			u, err := url.Parse(value)
+			if err != nil {
+				scrubbed[name][i] = scrub.MaskPlaceholder
+				continue
+			}
+			scrubbed[name][i] = o.url(u).String()
+		}
+	}
+	return scrubbed
+}
+
+// Values returns a scrubbed copy of v, such as the query parameters of a URL or a parsed form.
+func Values(v url.Values, opts ...Option) url.Values {
+	return newOptions(opts).values(v)
+}
+
+func (o *options) values(v map[string][]string) map[string][]string {
+	if v == nil {
+		return nil
+	}
+	scrubbed := make(map[string][]string, len(v))
+	for name, values := range v {
+		a, ok := o.action(name)
+		switch {
+		case !ok:
+			scrubbed[name] = append([]string(nil), values...)
+		case a != scrub.Omit:
+			replaced := make([]string, len(values))
+			for i, value := range values {
+				replaced[i] = a.Replace(value)
+			}
+			scrubbed[name] = replaced
+		}
+	}
+	return scrubbed
+}
+
+// Cookies returns scrubbed copies of cookies, selected by their names. Omitted cookies are left out.
+func Cookies(cookies []*http.Cookie, opts ...Option) []*http.Cookie {
+	return newOptions(opts).cookies(cookies)
+}
+
+func (o *options) cookies(cookies []*http.Cookie) []*http.Cookie {
+	if cookies == nil {
+		return nil
+	}
+	scrubbed := make([]*http.Cookie, 0, len(cookies))
+	for _, c := range cookies {
+		copied := *c
+		if a, ok := o.action(c.Name); ok {
+			if a == scrub.Omit {
+				continue
+			}
+			copied.Value = a.Replace(c.Value)
+		}
+		scrubbed = append(scrubbed, &copied)
+	}
+	return scrubbed
+}
+
+// URL returns a copy of u with the password removed and the query parameters, and any parameters in the
+// fragment, as in OAuth's "#access_token=...", scrubbed. The order of the parameters is kept.
+func URL(u *url.URL, opts ...Option) *url.URL {
+	return newOptions(opts).url(u)
+}
+
+func (o *options) url(u *url.URL) *url.URL {
+	if u == nil {
+		return nil
+	}
+	scrubbed := scrub.RewriteURL(*u, func(name, value string) (string, bool) {
+		a, ok := o.action(name)
+		switch {
+		case !ok:
+			return value, true
+		case a == scrub.Omit:
+			return "", false
+		}
+		return a.Replace(value), true
+	})
+	return &scrubbed
+}
+
+// Request returns a shallow copy of r, as r.Clone does, with its URL, RequestURI, headers, trailers and any
+// parsed forms scrubbed. The body isn't read or scrubbed.
+func Request(r *http.Request, opts ...Option) *http.Request {
+	return newOptions(opts).request(r)
+}
+
+func (o *options) request(r *http.Request) *http.Request {
+	scrubbed := r.Clone(r.Context())
+	scrubbed.URL = o.url(r.URL)
+	scrubbed.RequestURI = requestURI(r.RequestURI, scrubbed.URL)
+	scrubbed.Header = o.header(r.Header)
+	scrubbed.Trailer = o.header(r.Trailer)
+	scrubbed.Form = o.values(r.Form)
+	scrubbed.PostForm = o.values(r.PostForm)
+	if r.MultipartForm != nil {
+		form := *r.MultipartForm
+		form.Value = o.values(r.MultipartForm.Value)
+		scrubbed.MultipartForm = &form
+	}
+	return scrubbed
+}
+
+// requestURI returns the request target of a server request, rewritten from u, its scrubbed URL. Targets
+// that aren't paths or absolute URLs, such as "*" and the authority of a CONNECT request, hold no query or
+// password, and are returned as they are, as is the empty target of a client request.
+func requestURI(target string, u *url.URL) string {
+	switch {
+	case u == nil || target == "":
+		return target
+	case u.IsAbs():
+		return u.String()
+	case strings.HasPrefix(target, "/"):
+		return u.RequestURI()
+	}
+	return target
+}
+
+// Response returns a shallow copy of resp with its headers and trailers scrubbed, and its request, if any,
+// scrubbed as by Request. The body isn't read or scrubbed.
+func Response(resp *http.Response, opts ...Option) *http.Response {
+	o := newOptions(opts)
+	scrubbed := *resp
+	scrubbed.Header = o.header(resp.Header)
+	scrubbed.Trailer = o.header(resp.Trailer)
+	if resp.Request != nil {
+		scrubbed.Request = o.request(resp.Request)
+	}
+	return &scrubbed
+}
@@ -0,0 +1,559 @@
+package scrubjson
+
+import (
+	"bytes"
+	"encoding"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"math"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+	"unsafe"
+
+	"github.com/acj/scrub"
+)
+
+var (
+	marshalerType     = reflect.TypeFor[json.Marshaler]()
+	textMarshalerType = reflect.TypeFor[encoding.TextMarshaler]()
+	numberType        = reflect.TypeFor[json.Number]()
+)
+
+// startDetectingCyclesAfter is the nesting depth after which pointers, maps and slices are tracked to
+// detect cycles, as in encoding/json. Tracking them from the start would slow down the common case.
+const startDetectingCyclesAfter = 1000
+
+type seenKey struct {
+	ptr unsafe.Pointer
+	typ reflect.Type
+	len int
+}
+
+type encodeState struct {
+	bytes.Buffer
+	opts       *options
+	escapeHTML bool
+	// path holds the JSON keys leading to the value being encoded.
+	path     []string
+	ptrLevel int
+	ptrSeen  map[seenKey]struct{}
+
+	// scratch and leaf encode values that encoding/json handles on its own, such as strings and floats.
+	scratch bytes.Buffer
+	leaf    *json.Encoder
+}
+
+func newEncodeState(opts *options, escapeHTML bool) *encodeState {
+	e := &encodeState{opts: opts, escapeHTML: escapeHTML}
+	e.leaf = json.NewEncoder(&e.scratch)
+	e.leaf.SetEscapeHTML(escapeHTML)
+	return e
+}
+
+func (e *encodeState) marshal(v any) error {
+	if v == nil {
+		e.WriteString("null")
+		return nil
+	}
+	// As in encoding/json, only values reached through a pointer are addressable, so methods with pointer
+	// receivers are only called on those.
+	return e.encode(reflect.ValueOf(v), false)
+}
+
+// marshalLeaf appends the encoding/json encoding of v, for values such as json.Marshaler implementations
+// that encoding/json handles on its own.
+func (e *encodeState) marshalLeaf(v any) error {
+	e.scratch.Reset()
+	if err := e.leaf.Encode(v); err != nil {
+		return err
+	}
+	e.Write(bytes.TrimSuffix(e.scratch.Bytes(), []byte("\n")))
+	return nil
+}
+
+// encode appends the scrubbed encoding of v. If quoted is set, as for fields with the ",string" option,
+// strings, numbers and booleans are encoded inside a JSON string.
+func (e *encodeState) encode(v reflect.Value, quoted bool) error {
+	if !v.IsValid() {
+		e.WriteString("null")
+		return nil
+	}
+
+	t := v.Type()
+	if t.Kind() != reflect.Ptr && v.CanAddr() && implementsMarshaler(reflect.PointerTo(t)) {
+		return e.marshalLeaf(iface(v.Addr()))
+	}
+	if implementsMarshaler(t) {
+		if (t.Kind() == reflect.Ptr || t.Kind() == reflect.Interface) && v.IsNil() {
+			e.WriteString("null")
+			return nil
+		}
+		if v.CanInterface() || v.CanAddr() {
+			return e.marshalLeaf(iface(v))
+		}
+	}
+
+	switch v.Kind() {
+	case reflect.Bool:
+		e.Write(appendQuoted(e.AvailableBuffer(), quoted, strconv.AppendBool, v.Bool()))
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		e.Write(appendQuoted(e.AvailableBuffer(), quoted, appendInt, v.Int()))
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		e.Write(appendQuoted(e.AvailableBuffer(), quoted, appendUint, v.Uint()))
+	case reflect.Float32, reflect.Float64:
+		bits := t.Bits()
+		f := v.Float()
+		if math.IsInf(f, 0) || math.IsNaN(f) {
+			return &json.UnsupportedValueError{Value: v, Str: strconv.FormatFloat(f, 'g', -1, bits)}
+		}
+		e.Write(appendQuoted(e.AvailableBuffer(), quoted, func(b []byte, f float64) []byte {
+			return appendFloat(b, f, bits)
+		}, f))
+	case reflect.String:
+		if t == numberType {
+			// encoding/json validates numbers and writes them as they are.
+			if quoted {
+				e.WriteByte('"')
+			}
+			if err := e.marshalLeaf(json.Number(v.String())); err != nil {
+				return err
+			}
+			if quoted {
+				e.WriteByte('"')
+			}
+			return nil
+		}
+		e.string(v.String(), quoted)
+	case reflect.Interface:
+		if v.IsNil() {
+			e.WriteString("null")
+			return nil
+		}
+		return e.encode(v.Elem(), false)
+	case reflect.Ptr:
+		if v.IsNil() {
+			e.WriteString("null")
+			return nil
+		}
+		if err := e.enter(v); err != nil {
+			return err
+		}
+		if err := e.encode(v.Elem(), quoted); err != nil {
+			return err
+		}
+		e.leave(v)
+	case reflect.Map:
+		return e.encodeMap(v)
+	case reflect.Slice:
+		if v.IsNil() {
+			e.WriteString("null")
+			return nil
+		}
+		if elem := t.Elem(); elem.Kind() == reflect.Uint8 && !implementsMarshaler(reflect.PointerTo(elem)) {
+			b := append(e.AvailableBuffer(), '"')
+			b = base64.StdEncoding.AppendEncode(b, v.Bytes())
+			e.Write(append(b, '"'))
+			return nil
+		}
+		if err := e.enter(v); err != nil {
+			return err
+		}
+		if err := e.encodeArray(v); err != nil {
+			return err
+		}
+		e.leave(v)
+	case reflect.Array:
+		return e.encodeArray(v)
+	case reflect.Struct:
+		return e.encodeStruct(v)
+	default:
+		return &json.UnsupportedTypeError{Type: t}
+	}
+	return nil
+}
+
+// string appends s as a JSON string, which is itself quoted if quoted is set.
+func (e *encodeState) string(s string, quoted bool) {
+	if quoted {
+		inner := appendString(e.scratch.AvailableBuffer(), s, e.escapeHTML)
+		// The inner string is already escaped, so there's no HTML left to escape.
+		e.Write(appendString(e.AvailableBuffer(), inner, false))
+		return
+	}
+	e.Write(appendString(e.AvailableBuffer(), s, e.escapeHTML))
+}
+
+// enter records that the encoder is descending into the pointer, map or slice v. Once it's deep enough,
+// it tracks the values it has entered so that it can report cycles. Each successful call to enter must be
+// followed by a call to leave.
+func (e *encodeState) enter(v reflect.Value) error {
+	e.ptrLevel++
+	if e.ptrLevel <= startDetectingCyclesAfter {
+		return nil
+	}
+	key := newSeenKey(v)
+	if _, ok := e.ptrSeen[key]; ok {
+		return &json.UnsupportedValueError{Value: v, Str: fmt.Sprintf("encountered a cycle via %s", v.Type())}
+	}
+	if e.ptrSeen == nil {
+		e.ptrSeen = map[seenKey]struct{}{}
+	}
+	e.ptrSeen[key] = struct{}{}
+	return nil
+}
+
+func (e *encodeState) leave(v reflect.Value) {
+	if e.ptrLevel > startDetectingCyclesAfter {
+		delete(e.ptrSeen, newSeenKey(v))
+	}
+	e.ptrLevel--
+}
+
+func newSeenKey(v reflect.Value) seenKey {
+	key := seenKey{ptr: v.UnsafePointer(), typ: v.Type()}
+	if v.Kind() == reflect.Slice {
+		key.len = v.Len()
+	}
+	return key
+}
+
+func (e *encodeState) encodeArray(v reflect.Value) error {
+	e.WriteByte('[')
+	for i := 0; i < v.Len(); i++ {
+		if i > 0 {
+			e.WriteByte(',')
+		}
+		if err := e.encode(v.Index(i), false); err != nil {
+			return err
+		}
+	}
+	e.WriteByte(']')
+	return nil
+}
+
+func (e *encodeState) encodeStruct(v reflect.Value) error {
+	e.WriteByte('{')
+	first := true
+fields:
+	for _, f := range cachedFields(v.Type()) {
+		fv := v
+		for _, i := range f.index {
+			if fv.Kind() == reflect.Ptr {
+				if fv.IsNil() {
+					continue fields
+				}
+				fv = fv.Elem()
+			}
+			fv = fv.Field(i)
+		}
+
+		e.path = append(e.path, f.key)
+		if a, ok := e.action(f.goName, f.key, f.scrub); ok {
+			if a == scrub.Omit {
+				e.path = e.path[:len(e.path)-1]
+				continue
+			}
+			fv = replace(fv, a)
+		}
+		if (f.omitEmpty && isEmptyValue(fv)) || (f.omitZero && isZeroValue(fv)) {
+			e.path = e.path[:len(e.path)-1]
+			continue
+		}
+
+		if !first {
+			e.WriteByte(',')
+		}
+		first = false
+		e.string(f.key, false)
+		e.WriteByte(':')
+		if err := e.encode(fv, f.quoted); err != nil {
+			return err
+		}
+		e.path = e.path[:len(e.path)-1]
+	}
+	e.WriteByte('}')
+	return nil
+}
+
+func (e *encodeState) encodeMap(v reflect.Value) error {
+	kt := v.Type().Key()
+	switch kt.Kind() {
+	case reflect.String,
+		reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+	default:
+		if !kt.Implements(textMarshalerType) {
+			return &json.UnsupportedTypeError{Type: v.Type()}
+		}
+	}
+	if v.IsNil() {
+		e.WriteString("null")
+		return nil
+	}
+	if err := e.enter(v); err != nil {
+		return err
+	}
+
+	type entry struct {
+		key   string
+		value reflect.Value
+	}
+	entries := make([]entry, 0, v.Len())
+	iter := v.MapRange()
+	for iter.Next() {
+		key, err := mapKey(iter.Key())
+		if err != nil {
+			return &json.MarshalerError{Type: kt, Err: err}
+		}
+		entries = append(entries, entry{key: key, value: iter.Value()})
+	}
+	slices.SortFunc(entries, func(a, b entry) int {
+		return strings.Compare(a.key, b.key)
+	})
+
+	e.WriteByte('{')
+	first := true
+	for _, entry := range entries {
+		value := entry.value
+		e.path = append(e.path, entry.key)
+		if a, ok := e.action(entry.key, entry.key, false); ok {
+			if a == scrub.Omit {
+				e.path = e.path[:len(e.path)-1]
+				continue
+			}
+			value = replace(value, a)
+		}
+
+		if !first {
+			e.WriteByte(',')
+		}
+		first = false
+		e.string(entry.key, false)
+		e.WriteByte(':')
+		if err := e.encode(value, false); err != nil {
+			return err
+		}
+		e.path = e.path[:len(e.path)-1]
+	}
+	e.WriteByte('}')
+	e.leave(v)
+	return nil
+}
+
+// mapKey returns the JSON object key for the map key k, following the rules of encoding/json.
+func mapKey(k reflect.Value) (string, error) {
+	if k.Kind() == reflect.String {
+		return k.String(), nil
+	}
+	if tm, ok := iface(k).(encoding.TextMarshaler); ok {
+		if k.Kind() == reflect.Ptr && k.IsNil() {
+			return "", nil
+		}
+		b, err := tm.MarshalText()
+		return string(b), err
+	}
+	switch k.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return strconv.FormatInt(k.Int(), 10), nil
+	default:
+		return strconv.FormatUint(k.Uint(), 10), nil
+	}
+}
+
+// action returns the action for the field or map entry at the end of e.path, if it's selected for
+// scrubbing. goName and key are the same for map entries.
+func (e *encodeState) action(goName, key string, tagged bool) (scrub.Action, bool) {
+	for _, r := range e.opts.rules {
+		if r.Matches(goName, e.path) || (key != goName && r.Matches(key, e.path)) {
+			return r.ActionOrDefault(), true
+		}
+	}
+	if tagged {
+		return e.opts.tagAction, true
+	}
+	return "", false
+}
+
+// replace returns the value that a replaces v with. Strings, including those behind pointers and interfaces,
+// are replaced with a.Replace unless a is scrub.Zero; everything else is zeroed. The zero value of an
+// interface holding a value is the zero value of that value's type, so that a string in a map[string]any
+// becomes "".
+func replace(v reflect.Value, a scrub.Action) reflect.Value {
+	if a != scrub.Zero {
+		s := v
+		for (s.Kind() == reflect.Ptr || s.Kind() == reflect.Interface) && !s.IsNil() {
+			s = s.Elem()
+		}
+		if s.Kind() == reflect.String {
+			return reflect.ValueOf(a.Replace(s.String()))
+		}
+	}
+	t := v.Type()
+	if v.Kind() == reflect.Interface && !v.IsNil() {
+		t = v.Elem().Type()
+	}
+	// The zero value is addressable, like the field it replaces, so that encoding it can use methods with
+	// pointer receivers.
+	return reflect.New(t).Elem()
+}
+
+func implementsMarshaler(t reflect.Type) bool {
+	return t.Implements(marshalerType) || t.Implements(textMarshalerType)
+}
+
+// iface returns v as an interface value, even if it was reached through an unexported embedded struct, in
+// which case v must be addressable.
+func iface(v reflect.Value) any {
+	if !v.CanInterface() {
+		v = reflect.NewAt(v.Type(), unsafe.Pointer(v.UnsafeAddr())).Elem()
+	}
+	return v.Interface()
+}
+
+// isEmptyValue reports whether v is empty as far as the ",omitempty" option is concerned.
+func isEmptyValue(v reflect.Value) bool {
+	switch v.Kind() {
+	case reflect.Array, reflect.Map, reflect.Slice, reflect.String:
+		return v.Len() == 0
+	case reflect.Bool,
+		reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr,
+		reflect.Float32, reflect.Float64,
+		reflect.Interface, reflect.Ptr:
+		return v.IsZero()
+	}
+	return false
+}
+
+// isZeroValue reports whether v is zero as far as the ",omitzero" option is concerned, which uses the
+// value's IsZero method if it has one.
+func isZeroValue(v reflect.Value) bool {
+	type isZeroer interface{ IsZero() bool }
+	if (v.Kind() == reflect.Ptr || v.Kind() == reflect.Interface) && v.IsNil() {
+		return true
+	}
+	if z, ok := iface(v).(isZeroer); ok {
+		return z.IsZero()
+	}
+	if v.CanAddr() {
+		if z, ok := iface(v.Addr()).(isZeroer); ok {
+			return z.IsZero()
+		}
+	}
+	return v.IsZero()
+}
+
+// appendQuoted appends x using appendValue, wrapping it in quotes if quoted is set.
+func appendQuoted[T any](b []byte, quoted bool, appendValue func([]byte, T) []byte, x T) []byte {
+	if quoted {
+		b = append(b, '"')
+	}
+	b = appendValue(b, x)
+	if quoted {
+		b = append(b, '"')
+	}
+	return b
+}
+
+func appendInt(b []byte, i int64) []byte {
+	return strconv.AppendInt(b, i, 10)
+}
+
+func appendUint(b []byte, u uint64) []byte {
+	return strconv.AppendUint(b, u, 10)
+}
+
+// appendFloat appends f formatted as encoding/json does, which uses exponents only for very large and very
+// small numbers, like ES6.
+func appendFloat(b []byte, f float64, bits int) []byte {
+	abs := math.Abs(f)
+	format := byte('f')
+	if abs != 0 {
+		if bits == 64 && (abs < 1e-6 || abs >= 1e21) || bits == 32 && (float32(abs) < 1e-6 || float32(abs) >= 1e21) {
+			format = 'e'
+		}
+	}
+	b = strconv.AppendFloat(b, f, format, -1, bits)
+	if format == 'e' {
+		// Clean up e-09 to e-9.
+		n := len(b)
+		if n >= 4 && b[n-4] == 'e' && b[n-3] == '-' && b[n-2] == '0' {
+			b[n-2] = b[n-1]
+			b = b[:n-1]
+		}
+	}
+	return b
+}
+
+const hexDigits = "0123456789abcdef"
+
+// invalidUTF8 is what encoding/json replaces invalid UTF-8 with: the escape `\ufffd` before Go 1.27, and
+// the character itself since. It's taken from encoding/json so that the output matches on any toolchain.
+var invalidUTF8 = func() string {
+	b, err := json.Marshal("\xff")
+	if err != nil || len(b) < 2 {
+		return `\ufffd`
+	}
+	return string(b[1 : len(b)-1])
+}()
+
+// appendString appends s as a JSON string, escaped as encoding/json does. Invalid UTF-8 is replaced with
+// invalidUTF8, and U+2028 and U+2029 are escaped so that the output is safe to embed in JavaScript.
+func appendString[S []byte | string](b []byte, s S, escapeHTML bool) []byte {
+	b = append(b, '"')
+	start := 0
+	for i := 0; i < len(s); {
+		if c := s[i]; c < utf8.RuneSelf {
+			if c >= 0x20 && c != '"' && c != '\\' && (!escapeHTML || (c != '<' && c != '>' && c != '&')) {
+				i++
+				continue
+			}
+			b = append(b, s[start:i]...)
+			switch c {
+			case '\\', '"':
+				b = append(b, '\\', c)
+			case '\b':
+				b = append(b, '\\', 'b')
+			case '\f':
+				b = append(b, '\\', 'f')
+			case '\n':
+				b = append(b, '\\', 'n')
+			case '\r':
+				b = append(b, '\\', 'r')
+			case '\t':
+				b = append(b, '\\', 't')
+			default:
+				b = append(b, '\\', 'u', '0', '0', hexDigits[c>>4], hexDigits[c&0xF])
+			}
+			i++
+			start = i
+			continue
+		}
+		n := len(s) - i
+		if n > utf8.UTFMax {
+			n = utf8.UTFMax
+		}
+		r, size := utf8.DecodeRuneInString(string(s[i : i+n]))
+		if r == utf8.RuneError && size == 1 {
+			b = append(b, s[start:i]...)
+			b = append(b, invalidUTF8...)
+			i += size
+			start = i
+			continue
+		}
+		if r == '\u2028' || r == '\u2029' {
+			b = append(b, s[start:i]...)
+			b = append(b, '\\', 'u', '2', '0', '2', hexDigits[r&0xF])
+			i += size
+			start = i
+			continue
+		}
+		i += size
+	}
+	b = append(b, s[start:]...)
+	return append(b, '"')
+}
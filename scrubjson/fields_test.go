@@ -0,0 +1,85 @@
+package scrubjson
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTypeFields(t *testing.T) {
+	type inner struct {
+		A string
+		B string `json:"b"`
+	}
+
+	type other struct {
+		A string
+		C string
+	}
+
+	type conflicting struct {
+		inner
+		other
+		C string
+	}
+
+	type tagWins struct {
+		inner
+		Other other `json:"A"`
+	}
+
+	type pointerEmbed struct {
+		*inner
+		D int `json:"d,omitempty,omitzero"`
+	}
+
+	keys := func(v any) []string {
+		var keys []string
+		for _, f := range typeFields(reflect.TypeOf(v)) {
+			keys = append(keys, f.key)
+		}
+		return keys
+	}
+
+	t.Run("with fields at the same depth and key, drops both", func(t *testing.T) {
+		assert.Equal(t, []string{"b", "C"}, keys(conflicting{}))
+	})
+
+	t.Run("with a shallower tagged field, prefers it", func(t *testing.T) {
+		assert.Equal(t, []string{"b", "A"}, keys(tagWins{}))
+	})
+
+	t.Run("with options, records them", func(t *testing.T) {
+		fields := typeFields(reflect.TypeOf(pointerEmbed{}))
+		require.Len(t, fields, 3)
+		assert.Equal(t, []int{0, 0}, fields[0].index)
+		assert.True(t, fields[2].omitEmpty)
+		assert.True(t, fields[2].omitZero)
+	})
+
+	t.Run("with embedded structs, encodes like encoding/json", func(t *testing.T) {
+		for _, v := range []any{
+			conflicting{inner: inner{A: "a", B: "b"}, other: other{A: "x", C: "c"}, C: "top"},
+			tagWins{inner: inner{A: "a"}, Other: other{A: "o"}},
+			pointerEmbed{},
+			pointerEmbed{inner: &inner{A: "a"}, D: 1},
+		} {
+			expected, err := json.Marshal(v)
+			require.NoError(t, err)
+			got, err := Marshal(v)
+			require.NoError(t, err)
+			assert.Equal(t, string(expected), string(got))
+		}
+	})
+}
+
+func TestValidKey(t *testing.T) {
+	assert.True(t, validKey("user_name"))
+	assert.True(t, validKey("a-b.c"))
+	assert.False(t, validKey(""))
+	assert.False(t, validKey(`a"b`))
+	assert.False(t, validKey(`a\b`))
+}
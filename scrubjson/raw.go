@@ -0,0 +1,208 @@
+package scrubjson
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/acj/scrub"
+)
+
+// Scrub copies the JSON values read from src to dst, scrubbing the object entries selected by the rules
+// given with WithRules or WithNames. It's for JSON that isn't decoded into Go types, such as webhook
+// payloads, so there are no struct tags and rules match object keys. src may hold a stream of values, such
+// as newline-delimited JSON, and each value is written compactly on its own line.
+//
+// Scrub reads and writes one token at a time, so it only holds the keys leading to the current value and
+// that value's token in memory, however big the document is. Selected strings are replaced according to
+// the rule's action; selected numbers, booleans, objects and arrays are replaced with 0, false and null
+// respectively, unless the action is scrub.Omit, in which case the entry is removed.
+func Scrub(dst io.Writer, src io.Reader, opts ...Option) error {
+	o := newOptions(opts)
+	dec := json.NewDecoder(src)
+	dec.UseNumber()
+	w := bufio.NewWriter(dst)
+	s := &rawScrubber{dec: dec, w: w, opts: &o}
+
+	for {
+		tok, err := dec.Token()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return err
+		}
+		if err := s.value(tok); err != nil {
+			return err
+		}
+		if err := w.WriteByte('\n'); err != nil {
+			return err
+		}
+	}
+	return w.Flush()
+}
+
+// ScrubBytes is like Scrub, but scrubs the JSON in data and returns the result.
+func ScrubBytes(data []byte, opts ...Option) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := Scrub(&buf, bytes.NewReader(data), opts...); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+type rawScrubber struct {
+	dec  *json.Decoder
+	w    *bufio.Writer
+	opts *options
+	// path holds the object keys leading to the current value.
+	path []string
+	buf  []byte
+}
+
+// value writes the value starting with tok.
+func (s *rawScrubber) value(tok json.Token) error {
+	switch tok := tok.(type) {
+	case json.Delim:
+		if tok == '{' {
+			return s.object()
+		}
+		return s.array()
+	case string:
+		s.string(tok)
+	case json.Number:
+		s.w.WriteString(tok.String())
+	case bool:
+		if tok {
+			s.w.WriteString("true")
+		} else {
+			s.w.WriteString("false")
+		}
+	case nil:
+		s.w.WriteString("null")
+	default:
+		return fmt.Errorf("scrubjson: unexpected token %v", tok)
+	}
+	return nil
+}
+
+func (s *rawScrubber) object() error {
+	s.w.WriteByte('{')
+	first := true
+	for s.dec.More() {
+		tok, err := s.dec.Token()
+		if err != nil {
+			return err
+		}
+		key := tok.(string)
+		if tok, err = s.dec.Token(); err != nil {
+			return err
+		}
+
+		s.path = append(s.path, key)
+		a, scrubbed := s.action(key)
+		if scrubbed && a == scrub.Omit {
+			if err := s.skip(tok); err != nil {
+				return err
+			}
+			s.path = s.path[:len(s.path)-1]
+			continue
+		}
+
+		if !first {
+			s.w.WriteByte(',')
+		}
+		first = false
+		s.string(key)
+		s.w.WriteByte(':')
+		if scrubbed {
+			err = s.replace(tok, a)
+		} else {
+			err = s.value(tok)
+		}
+		if err != nil {
+			return err
+		}
+		s.path = s.path[:len(s.path)-1]
+	}
+	if _, err := s.dec.Token(); err != nil {
+		return err
+	}
+	return s.w.WriteByte('}')
+}
+
+func (s *rawScrubber) array() error {
+	s.w.WriteByte('[')
+	for i := 0; s.dec.More(); i++ {
+		if i > 0 {
+			s.w.WriteByte(',')
+		}
+		tok, err := s.dec.Token()
+		if err != nil {
+			return err
+		}
+		if err := s.value(tok); err != nil {
+			return err
+		}
+	}
+	if _, err := s.dec.Token(); err != nil {
+		return err
+	}
+	return s.w.WriteByte(']')
+}
+
+// replace writes what a replaces the value starting with tok with, skipping the value.
+func (s *rawScrubber) replace(tok json.Token, a scrub.Action) error {
+	switch tok := tok.(type) {
+	case json.Delim:
+		s.w.WriteString("null")
+		return s.skip(tok)
+	case string:
+		s.string(a.Replace(tok))
+	case json.Number:
+		s.w.WriteByte('0')
+	case bool:
+		s.w.WriteString("false")
+	default:
+		s.w.WriteString("null")
+	}
+	return nil
+}
+
+// skip reads past the value starting with tok.
+func (s *rawScrubber) skip(tok json.Token) error {
+	if _, ok := tok.(json.Delim); !ok {
+		return nil
+	}
+	for depth := 1; depth > 0; {
+		tok, err := s.dec.Token()
+		if err != nil {
+			return err
+		}
+		if d, ok := tok.(json.Delim); ok {
+			if d == '{' || d == '[' {
+				depth++
+			} else {
+				depth--
+			}
+		}
+	}
+	return nil
+}
+
+func (s *rawScrubber) action(key string) (scrub.Action, bool) {
+	for _, r := range s.opts.rules {
+		if r.Matches(key, s.path) {
+			return r.ActionOrDefault(), true
+		}
+	}
+	return "", false
+}
+
+func (s *rawScrubber) string(str string) {
+	s.buf = appendString(s.buf[:0], str, false)
+	s.w.Write(s.buf)
+}
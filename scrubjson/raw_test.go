@@ -0,0 +1,140 @@
+package scrubjson
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/acj/scrub"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const webhook = `{
+	"event": "charge.succeeded",
+	"data": {
+		"customer": {"name": "Testy Tester", "email": "testy@example.com", "address": {"street": "742 Evergreen Terrace"}},
+		"card": {"number": "4242424242424242", "cvc": 123, "verified": true, "meta": null},
+		"api_token": "sk_live_123",
+		"refresh_token": "rt_456"
+	},
+	"items": [{"sku": "A1", "secret": {"a": [1, {"b": 2}]}}, {"sku": "B2", "secret": "s"}],
+	"amount": 12.50
+}`
+
+func TestScrubBytes(t *testing.T) {
+	t.Run("without rules, writes the input compactly", func(t *testing.T) {
+		got, err := ScrubBytes([]byte(webhook))
+		require.NoError(t, err)
+
+		var expected bytes.Buffer
+		require.NoError(t, json.Compact(&expected, []byte(webhook)))
+		assert.Equal(t, expected.String()+"\n", string(got))
+	})
+
+	t.Run("with names, globs and paths, scrubs the selected entries", func(t *testing.T) {
+		got, err := ScrubBytes([]byte(webhook), WithRules(
+			scrub.Rule{Name: "email", Action: scrub.Hash},
+			scrub.Rule{Glob: "*_token", Action: scrub.Omit},
+			scrub.Rule{Path: "data.card.*", Action: scrub.Mask},
+			scrub.Rule{Path: "**.address"},
+			scrub.Rule{Name: "secret", Path: "items.secret"},
+		))
+		require.NoError(t, err)
+
+		assert.JSONEq(t, `{
+			"event": "charge.succeeded",
+			"data": {
+				"customer": {"name": "Testy Tester", "email": "`+scrub.Hash.Replace("testy@example.com")+`", "address": null},
+				"card": {"number": "****", "cvc": 0, "verified": false, "meta": null}
+			},
+			"items": [{"sku": "A1", "secret": null}, {"sku": "B2", "secret": ""}],
+			"amount": 12.50
+		}`, string(got))
+	})
+
+	t.Run("with numbers, preserves them exactly", func(t *testing.T) {
+		got, err := ScrubBytes([]byte(`{"big": 12345678901234567890, "f": 1.50e3}`))
+		require.NoError(t, err)
+		assert.Equal(t, "{\"big\":12345678901234567890,\"f\":1.50e3}\n", string(got))
+	})
+
+	t.Run("with several values, writes each on its own line", func(t *testing.T) {
+		got, err := ScrubBytes([]byte("{\"a\": \"<x>\"}\n[1, 2]\n\"s\"\n"), WithNames("a"))
+		require.NoError(t, err)
+		assert.Equal(t, "{\"a\":\"\"}\n[1,2]\n\"s\"\n", string(got))
+	})
+
+	t.Run("with invalid JSON, returns an error", func(t *testing.T) {
+		_, err := ScrubBytes([]byte(`{"a": }`))
+		assert.Error(t, err)
+		_, err = ScrubBytes([]byte(`{"a": [1, 2}`))
+		assert.Error(t, err)
+	})
+}
+
+// endlessArray produces the JSON document {"items": [{"password": "hunter2"}, ...]} with n elements,
+// without holding it in memory.
+type endlessArray struct {
+	n, i int
+	r    io.Reader
+}
+
+func (e *endlessArray) Read(p []byte) (int, error) {
+	for {
+		if e.r != nil {
+			if n, err := e.r.Read(p); n > 0 || err != io.EOF {
+				return n, err
+			}
+		}
+		switch {
+		case e.i == 0:
+			e.r = strings.NewReader(`{"items": [`)
+		case e.i <= e.n:
+			sep := ","
+			if e.i == e.n {
+				sep = ""
+			}
+			e.r = strings.NewReader(`{"password": "hunter2"}` + sep)
+		case e.i == e.n+1:
+			e.r = strings.NewReader(`]}`)
+		default:
+			return 0, io.EOF
+		}
+		e.i++
+	}
+}
+
+// countingWriter counts the occurrences of a substring in what's written to it, without holding on to
+// more than the end of the last write, in case the substring is split across writes.
+type countingWriter struct {
+	substr  []byte
+	tail    []byte
+	matches int
+}
+
+func (c *countingWriter) Write(p []byte) (int, error) {
+	buf := append(c.tail, p...)
+	c.matches += bytes.Count(buf, c.substr)
+	if i := bytes.LastIndex(buf, c.substr); i >= 0 {
+		buf = buf[i+len(c.substr):]
+	}
+	c.tail = append([]byte(nil), buf[max(0, len(buf)-len(c.substr)+1):]...)
+	return len(p), nil
+}
+
+func TestScrub(t *testing.T) {
+	t.Run("with a large stream, scrubs it as it goes", func(t *testing.T) {
+		scrubbed := countingWriter{substr: []byte(`{"password":""}`)}
+		err := Scrub(&scrubbed, &endlessArray{n: 100_000}, WithNames("password"))
+		require.NoError(t, err)
+		assert.Equal(t, 100_000, scrubbed.matches)
+
+		leaked := countingWriter{substr: []byte("hunter2")}
+		err = Scrub(&leaked, &endlessArray{n: 100_000}, WithNames("password"))
+		require.NoError(t, err)
+		assert.Zero(t, leaked.matches)
+	})
+}
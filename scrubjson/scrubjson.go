@@ -0,0 +1,121 @@
+// Package scrubjson encodes values as JSON while scrubbing them, instead of copying and scrubbing the values
+// before encoding them.
+//
+// The output matches encoding/json, including struct tags, embedded structs, sorted map keys and calls to
+// json.Marshaler and encoding.TextMarshaler implementations, except that selected fields and map entries are
+// zeroed, omitted, masked, hashed or faked as they're written. Fields annotated with a `scrub:"true"` struct
+// tag are always selected, and WithRules selects more by name, glob or path. The values passed in are never
+// modified. Values that marshal themselves are written as they are, so the fields inside them can't be
+// scrubbed.
+//
+// Scrub and ScrubBytes apply the same rules to JSON documents that aren't decoded into Go values at all.
+package scrubjson
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+
+	"github.com/acj/scrub"
+)
+
+// Option configures Marshal and NewEncoder.
+type Option func(*options)
+
+type options struct {
+	rules     []scrub.Rule
+	tagAction scrub.Action
+}
+
+// WithRules also scrubs the struct fields and map entries selected by rules. A rule's name and glob match
+// either a field's Go name or its JSON key, and a rule's path is made up of JSON keys, such as
+// "user.address.street". When more than one rule matches, the first one wins.
+func WithRules(rules ...scrub.Rule) Option {
+	return func(o *options) {
+		o.rules = append(o.rules, rules...)
+	}
+}
+
+// WithNames also zeroes the struct fields and map entries with the given names. It's shorthand for
+// WithRules with a Name rule for each name.
+func WithNames(names ...string) Option {
+	return func(o *options) {
+		for _, name := range names {
+			o.rules = append(o.rules, scrub.Rule{Name: name})
+		}
+	}
+}
+
+// WithTagAction sets the action for fields annotated with a `scrub:"true"` struct tag, which defaults to
+// scrub.Zero. Rules that match those fields take precedence.
+func WithTagAction(a scrub.Action) Option {
+	return func(o *options) {
+		o.tagAction = a
+	}
+}
+
+func newOptions(opts []Option) options {
+	o := options{tagAction: scrub.Zero}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	return o
+}
+
+// Marshal returns the scrubbed JSON encoding of v. It's like json.Marshal, but scrubs values as described
+// in the package documentation.
+func Marshal(v any, opts ...Option) ([]byte, error) {
+	o := newOptions(opts)
+	e := newEncodeState(&o, true)
+	if err := e.marshal(v); err != nil {
+		return nil, err
+	}
+	return bytes.Clone(e.Bytes()), nil
+}
+
+// An Encoder writes scrubbed JSON values to an output stream. It's like json.Encoder, but scrubs values as
+// described in the package documentation.
+type Encoder struct {
+	w          io.Writer
+	opts       options
+	escapeHTML bool
+	prefix     string
+	indent     string
+}
+
+// NewEncoder returns a new Encoder that writes to w.
+func NewEncoder(w io.Writer, opts ...Option) *Encoder {
+	return &Encoder{w: w, opts: newOptions(opts), escapeHTML: true}
+}
+
+// Encode writes the scrubbed JSON encoding of v to the stream, followed by a newline character.
+func (enc *Encoder) Encode(v any) error {
+	e := newEncodeState(&enc.opts, enc.escapeHTML)
+	if err := e.marshal(v); err != nil {
+		return err
+	}
+	e.WriteByte('\n')
+
+	b := e.Bytes()
+	if enc.prefix != "" || enc.indent != "" {
+		var indented bytes.Buffer
+		if err := json.Indent(&indented, b, enc.prefix, enc.indent); err != nil {
+			return err
+		}
+		b = indented.Bytes()
+	}
+	_, err := enc.w.Write(b)
+	return err
+}
+
+// SetEscapeHTML specifies whether problematic HTML characters should be escaped inside JSON quoted
+// strings, as with json.Encoder.SetEscapeHTML. The default is true.
+func (enc *Encoder) SetEscapeHTML(on bool) {
+	enc.escapeHTML = on
+}
+
+// SetIndent instructs the encoder to format each subsequent encoded value as if indented by json.Indent.
+func (enc *Encoder) SetIndent(prefix, indent string) {
+	enc.prefix = prefix
+	enc.indent = indent
+}
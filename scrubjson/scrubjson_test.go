@@ -0,0 +1,322 @@
+package scrubjson
+
+import (
+	"bytes"
+	"encoding/json"
+	"math"
+	"strings"
+	"testing"
+
+	"github.com/acj/scrub"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMarshal(t *testing.T) {
+	type address struct {
+		City   string `json:"city"`
+		Street string `json:"street" scrub:"true"`
+	}
+	type user struct {
+		Name     string            `json:"name"`
+		Email    string            `json:"email,omitempty" scrub:"true"`
+		Password string            `json:"password"`
+		Age      int               `json:"age,string"`
+		Home     *address          `json:"home"`
+		Places   []address         `json:"places"`
+		Labels   map[string]string `json:"labels,omitempty"`
+		Extra    any               `json:"extra"`
+	}
+	newUser := func() *user {
+		return &user{
+			Name:     "Testy Tester",
+			Email:    "testy@example.com",
+			Password: "hunter2",
+			Age:      26,
+			Home:     &address{City: "Springfield", Street: "742 Evergreen Terrace"},
+			Places:   []address{{City: "Shelbyville", Street: "1 Main St"}},
+			Labels:   map[string]string{"token": "abc123", "team": "<blue>"},
+			Extra:    map[string]any{"password": "hunter2", "pin": 1234.0},
+		}
+	}
+
+	t.Run("with tagged fields, zeroes them while encoding", func(t *testing.T) {
+		u := newUser()
+		b, err := Marshal(u)
+		require.NoError(t, err)
+
+		assert.JSONEq(t, `{
+			"name": "Testy Tester",
+			"password": "hunter2",
+			"age": "26",
+			"home": {"city": "Springfield", "street": ""},
+			"places": [{"city": "Shelbyville", "street": ""}],
+			"labels": {"team": "<blue>", "token": "abc123"},
+			"extra": {"password": "hunter2", "pin": 1234}
+		}`, string(b))
+		assert.Equal(t, newUser(), u)
+	})
+
+	t.Run("with names, scrubs fields by Go name or JSON key, and map entries by key", func(t *testing.T) {
+		b, err := Marshal(newUser(), WithNames("Password", "token", "password"))
+		require.NoError(t, err)
+
+		var got map[string]any
+		require.NoError(t, json.Unmarshal(b, &got))
+		assert.Equal(t, "", got["password"])
+		assert.Equal(t, map[string]any{"team": "<blue>", "token": ""}, got["labels"])
+		assert.Equal(t, map[string]any{"password": "", "pin": 1234.0}, got["extra"])
+	})
+
+	t.Run("with paths, scrubs only the values at those paths", func(t *testing.T) {
+		b, err := Marshal(newUser(), WithRules(
+			scrub.Rule{Path: "home.city"},
+			scrub.Rule{Path: "**.pin", Action: scrub.Omit},
+		))
+		require.NoError(t, err)
+
+		var got map[string]any
+		require.NoError(t, json.Unmarshal(b, &got))
+		assert.Equal(t, map[string]any{"city": "", "street": ""}, got["home"])
+		assert.Equal(t, []any{map[string]any{"city": "Shelbyville", "street": ""}}, got["places"])
+		assert.Equal(t, map[string]any{"password": "hunter2"}, got["extra"])
+	})
+
+	t.Run("with actions, omits, masks or hashes values", func(t *testing.T) {
+		b, err := Marshal(newUser(),
+			WithRules(
+				scrub.Rule{Name: "Password", Action: scrub.Hash},
+				scrub.Rule{Name: "labels", Action: scrub.Omit},
+				scrub.Rule{Name: "age", Action: scrub.Mask},
+				scrub.Rule{Name: "extra", Action: scrub.Mask},
+			),
+			WithTagAction(scrub.Mask),
+		)
+		require.NoError(t, err)
+
+		assert.JSONEq(t, `{
+			"name": "Testy Tester",
+			"email": "****",
+			"password": "f52fbd32b2b3b86ff88ef6c490628285f482af15ddcb29541f94bcf526a3f6c7",
+			"age": "0",
+			"home": {"city": "Springfield", "street": "****"},
+			"places": [{"city": "Shelbyville", "street": "****"}],
+			"extra": null
+		}`, string(b))
+	})
+
+	t.Run("with Omit for a tagged field, omits it", func(t *testing.T) {
+		b, err := Marshal(address{City: "Springfield", Street: "742 Evergreen Terrace"}, WithTagAction(scrub.Omit))
+		require.NoError(t, err)
+		assert.Equal(t, `{"city":"Springfield"}`, string(b))
+	})
+
+	t.Run("with the first of several matching rules, uses its action", func(t *testing.T) {
+		b, err := Marshal(address{City: "Springfield"}, WithRules(
+			scrub.Rule{Name: "city", Action: scrub.Mask},
+			scrub.Rule{Name: "City", Action: scrub.Omit},
+		))
+		require.NoError(t, err)
+		assert.Equal(t, `{"city":"****","street":""}`, string(b))
+	})
+
+	t.Run("with values that marshal themselves, writes them as they are", func(t *testing.T) {
+		b, err := Marshal(map[string]json.RawMessage{"password": json.RawMessage(`{"a": 1}`)})
+		require.NoError(t, err)
+		assert.Equal(t, `{"password":{"a":1}}`, string(b))
+	})
+}
+
+// zeroer implements IsZero, for omitzero, which needs a package-level type.
+type zeroer struct{ Value int }
+
+func (z zeroer) IsZero() bool { return z.Value == 1 }
+
+// ptrMarshaler implements json.Marshaler with a pointer receiver, which needs a package-level type, so that
+// encoding/json only calls it on addressable values.
+type ptrMarshaler struct{ X int }
+
+func (*ptrMarshaler) MarshalJSON() ([]byte, error) { return []byte(`"ptr"`), nil }
+
+func TestMarshalMatchesEncodingJSON(t *testing.T) {
+	type address struct {
+		City   string `json:"city"`
+		Street string `json:"street" scrub:"true"`
+	}
+	type embedded struct {
+		ID     int    `json:"id"`
+		Secret string `scrub:"true"`
+	}
+
+	type shadow struct {
+		ID string
+	}
+
+	type parity struct {
+		embedded
+		*shadow
+		Name      string          `json:"name,omitempty"`
+		Note      string          `json:"-"`
+		Dash      string          `json:"-,"`
+		Count     int64           `json:",string"`
+		Ratio     float32         `json:"ratio"`
+		Big       float64         `json:"big"`
+		Flag      bool            `json:"flag,string"`
+		Quoted    string          `json:"quoted,string"`
+		Bytes     []byte          `json:"bytes"`
+		Nil       []int           `json:"nil"`
+		Empty     []int           `json:"empty,omitempty"`
+		Array     [2]uint8        `json:"array"`
+		IntKeys   map[int]string  `json:"int_keys"`
+		Number    json.Number     `json:"number"`
+		Raw       json.RawMessage `json:"raw"`
+		HTML      string          `json:"html"`
+		Iface     any             `json:"iface"`
+		Ptr       *int            `json:"ptr"`
+		Zero      zeroer          `json:"zero,omitzero"`
+		Unicode   string          `json:"unicode"`
+		unexposed string
+	}
+
+	n := 7
+	values := map[string]any{
+		"nil":                           nil,
+		"primitive":                     42,
+		"string":                        "a<b>&c ",
+		"slice":                         []any{1, "two", 3.5, nil, true},
+		"escapes":                       "tab\t nl\n cr\r bs\b ff\f nul\x00 quote\" slash\\ del\x7f bad\xff sep\u2028\u2029 é",
+		"floats":                        []any{1e-7, 1e20, 1e21, -0.0, 123.456, float32(3.14), float32(1e-7), 1.0},
+		"pointer receiver by value":     ptrMarshaler{X: 1},
+		"pointer receiver in a value":   struct{ A ptrMarshaler }{A: ptrMarshaler{X: 1}},
+		"pointer receiver in a pointer": &struct{ A ptrMarshaler }{A: ptrMarshaler{X: 1}},
+		"struct": parity{
+			embedded:  embedded{ID: 1},
+			shadow:    &shadow{ID: "shadowed"},
+			Dash:      "dash",
+			Count:     12,
+			Ratio:     0.1,
+			Big:       1e21,
+			Flag:      true,
+			Quoted:    `say "hi"`,
+			Bytes:     []byte("hello"),
+			Empty:     []int{},
+			Array:     [2]uint8{1, 2},
+			IntKeys:   map[int]string{10: "ten", 2: "two"},
+			Number:    "12.50",
+			Raw:       json.RawMessage(`[1, 2]`),
+			HTML:      "<script>",
+			Iface:     &address{City: "Springfield"},
+			Ptr:       &n,
+			Zero:      zeroer{Value: 1},
+			Unicode:   "héllo",
+			unexposed: "hidden",
+		},
+	}
+
+	for name, v := range values {
+		t.Run("with "+name+", matches json.Marshal", func(t *testing.T) {
+			expected, err := json.Marshal(v)
+			require.NoError(t, err)
+			got, err := Marshal(v, WithTagAction(scrub.Omit), WithRules(scrub.Rule{Name: "street", Action: scrub.Omit}))
+			require.NoError(t, err)
+
+			expected = bytes.ReplaceAll(expected, []byte(`,"Secret":""`), nil)
+			expected = bytes.ReplaceAll(expected, []byte(`,"street":""`), nil)
+			assert.Equal(t, string(expected), string(got))
+		})
+	}
+
+	t.Run("with unsupported values, returns the same errors as json.Marshal", func(t *testing.T) {
+		_, expected := json.Marshal(map[string]any{"c": make(chan int)})
+		_, err := Marshal(map[string]any{"c": make(chan int)})
+		assert.Equal(t, expected, err)
+
+		_, err = Marshal(map[string]float64{"nan": math.NaN()})
+		var unsupported *json.UnsupportedValueError
+		assert.ErrorAs(t, err, &unsupported)
+
+		type cyclic struct{ Next *cyclic }
+		c := &cyclic{}
+		c.Next = c
+		_, err = Marshal(c)
+		assert.ErrorAs(t, err, &unsupported)
+	})
+}
+
+func TestEncoder(t *testing.T) {
+	t.Run("with several values, writes each on its own line", func(t *testing.T) {
+		type address struct {
+			City   string `json:"city"`
+			Street string `json:"street" scrub:"true"`
+		}
+		var buf bytes.Buffer
+		enc := NewEncoder(&buf, WithNames("city"))
+		require.NoError(t, enc.Encode(address{City: "Springfield", Street: "742 Evergreen Terrace"}))
+		require.NoError(t, enc.Encode([]string{"<a>"}))
+
+		assert.Equal(t, "{\"city\":\"\",\"street\":\"\"}\n[\"\\u003ca\\u003e\"]\n", buf.String())
+	})
+
+	t.Run("with indentation and HTML escaping disabled, matches json.Encoder", func(t *testing.T) {
+		v := map[string]any{"html": "<a>", "list": []int{1, 2}}
+		var expected, got strings.Builder
+		jsonEnc := json.NewEncoder(&expected)
+		jsonEnc.SetIndent(">", "  ")
+		jsonEnc.SetEscapeHTML(false)
+		require.NoError(t, jsonEnc.Encode(v))
+
+		enc := NewEncoder(&got)
+		enc.SetIndent(">", "  ")
+		enc.SetEscapeHTML(false)
+		require.NoError(t, enc.Encode(v))
+
+		assert.Equal(t, expected.String(), got.String())
+	})
+}
+
+func BenchmarkMarshal(b *testing.B) {
+	type address struct {
+		City   string `json:"city"`
+		Street string `json:"street" scrub:"true"`
+	}
+	type user struct {
+		Name     string   `json:"name"`
+		Email    string   `json:"email,omitempty" scrub:"true"`
+		Password string   `json:"password"`
+		Home     *address `json:"home"`
+		Labels   map[string]string
+	}
+	users := make([]*user, 100)
+	for i := range users {
+		users[i] = &user{
+			Name:     "Testy Tester",
+			Email:    "testy@example.com",
+			Password: "hunter2",
+			Home:     &address{City: "Springfield", Street: "742 Evergreen Terrace"},
+			Labels:   map[string]string{"token": "abc123"},
+		}
+	}
+
+	b.Run("scrubjson", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			if _, err := Marshal(users, WithNames("Password")); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+
+	b.Run("copy, scrub and json.Marshal", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			copied := scrub.Copy(users)
+			for _, u := range copied {
+				scrub.TaggedFields(u)
+				scrub.NamedFields(u, "Password")
+			}
+			if _, err := json.Marshal(copied); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+}
@@ -0,0 +1,81 @@
+// Package scrubslog scrubs struct values in log/slog attributes before they're logged.
+//
+// Struct values, pointers to structs and slices of either found in attributes are replaced with scrubbed
+// copies, leaving the original values unchanged. Fields annotated with a `scrub:"true"` struct tag are
+// always scrubbed; fields with any of the names passed to ReplaceAttr or NewHandler are scrubbed as well.
+// Values implementing slog.LogValuer are resolved first, and the attributes inside groups are scrubbed
+// individually.
+package scrubslog
+
+import (
+	"context"
+	"log/slog"
+
+	"github.com/acj/scrub/internal/scrubvalue"
+)
+
+// ReplaceAttr returns a function for use as slog.HandlerOptions.ReplaceAttr that scrubs struct values in
+// attributes. This is useful when you construct the handler yourself; otherwise, see NewHandler.
+func ReplaceAttr(names ...string) func(groups []string, a slog.Attr) slog.Attr {
+	return func(_ []string, a slog.Attr) slog.Attr {
+		return scrubAttr(a, names)
+	}
+}
+
+// Handler is a slog.Handler that scrubs struct values in attributes before passing records on to another
+// handler.
+type Handler struct {
+	handler slog.Handler
+	names   []string
+}
+
+// NewHandler returns a Handler that scrubs struct values in attributes, including those added with
+// WithAttrs, before passing them on to h.
+func NewHandler(h slog.Handler, names ...string) *Handler {
+	return &Handler{handler: h, names: names}
+}
+
+// Enabled reports whether the underlying handler handles records at the given level.
+func (h *Handler) Enabled(ctx context.Context, level slog.Level) bool {
+	return h.handler.Enabled(ctx, level)
+}
+
+// Handle scrubs the attributes of r and passes the result on to the underlying handler.
+func (h *Handler) Handle(ctx context.Context, r slog.Record) error {
+	scrubbed := slog.NewRecord(r.Time, r.Level, r.Message, r.PC)
+	r.Attrs(func(a slog.Attr) bool {
+		scrubbed.AddAttrs(scrubAttr(a, h.names))
+		return true
+	})
+	return h.handler.Handle(ctx, scrubbed)
+}
+
+// WithAttrs returns a Handler whose underlying handler has the scrubbed attrs.
+func (h *Handler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return &Handler{handler: h.handler.WithAttrs(scrubAttrs(attrs, h.names)), names: h.names}
+}
+
+// WithGroup returns a Handler whose underlying handler has the given group.
+func (h *Handler) WithGroup(name string) slog.Handler {
+	return &Handler{handler: h.handler.WithGroup(name), names: h.names}
+}
+
+func scrubAttrs(attrs []slog.Attr, names []string) []slog.Attr {
+	scrubbed := make([]slog.Attr, len(attrs))
+	for i, a := range attrs {
+		scrubbed[i] = scrubAttr(a, names)
+	}
+	return scrubbed
+}
+
+func scrubAttr(a slog.Attr, names []string) slog.Attr {
+	v := a.Value.Resolve()
+	switch v.Kind() {
+	case slog.KindGroup:
+		return slog.Attr{Key: a.Key, Value: slog.GroupValue(scrubAttrs(v.Group(), names)...)}
+	case slog.KindAny:
+		return slog.Attr{Key: a.Key, Value: slog.AnyValue(scrubvalue.Copy(v.Any(), names))}
+	default:
+		return slog.Attr{Key: a.Key, Value: v}
+	}
+}
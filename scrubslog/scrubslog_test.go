@@ -0,0 +1,179 @@
+package scrubslog
+
+import (
+	"bytes"
+	"log/slog"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// loggedEmail implements slog.LogValuer, which needs a package-level type, by logging a struct value.
+type loggedEmail struct {
+	email string
+}
+
+func (e loggedEmail) LogValue() slog.Value {
+	return slog.AnyValue(struct {
+		Email string `scrub:"true"`
+	}{Email: e.email})
+}
+
+// withoutTime wraps replace, which may be nil, to also remove the time from records, so that they can be
+// compared.
+func withoutTime(replace func([]string, slog.Attr) slog.Attr) func([]string, slog.Attr) slog.Attr {
+	return func(groups []string, a slog.Attr) slog.Attr {
+		if len(groups) == 0 && a.Key == slog.TimeKey {
+			return slog.Attr{}
+		}
+		if replace != nil {
+			return replace(groups, a)
+		}
+		return a
+	}
+}
+
+func TestReplaceAttr(t *testing.T) {
+	logTo := func(buf *bytes.Buffer, names ...string) *slog.Logger {
+		return slog.New(slog.NewJSONHandler(buf, &slog.HandlerOptions{ReplaceAttr: withoutTime(ReplaceAttr(names...))}))
+	}
+
+	t.Run("with a struct value, logs a scrubbed copy", func(t *testing.T) {
+		type user struct {
+			Name  string
+			Email string `scrub:"true"`
+		}
+		var buf bytes.Buffer
+		u := user{Name: "Testy Tester", Email: "testy@example.com"}
+
+		logTo(&buf).Info("hello", "user", u)
+
+		assert.JSONEq(t, `{"level": "INFO", "msg": "hello", "user": {"Name": "Testy Tester", "Email": ""}}`, buf.String())
+		assert.Equal(t, "testy@example.com", u.Email)
+	})
+
+	t.Run("with names, also scrubs the named fields", func(t *testing.T) {
+		type user struct {
+			Name     string
+			Password string
+		}
+		var buf bytes.Buffer
+
+		logTo(&buf, "Password").Info("hello", "user", user{Name: "Testy Tester", Password: "hunter2"})
+
+		assert.JSONEq(t, `{"level": "INFO", "msg": "hello", "user": {"Name": "Testy Tester", "Password": ""}}`, buf.String())
+	})
+
+	t.Run("with a LogValuer that resolves to a struct, logs a scrubbed copy", func(t *testing.T) {
+		var buf bytes.Buffer
+
+		logTo(&buf).Info("hello", "user", loggedEmail{email: "testy@example.com"})
+
+		assert.JSONEq(t, `{"level": "INFO", "msg": "hello", "user": {"Email": ""}}`, buf.String())
+	})
+}
+
+func TestHandler(t *testing.T) {
+	logTo := func(buf *bytes.Buffer, names ...string) *slog.Logger {
+		return slog.New(NewHandler(slog.NewJSONHandler(buf, &slog.HandlerOptions{ReplaceAttr: withoutTime(nil)}), names...))
+	}
+
+	t.Run("with a struct value, logs a scrubbed copy", func(t *testing.T) {
+		type user struct {
+			Name  string
+			Email string `scrub:"true"`
+		}
+		var buf bytes.Buffer
+		u := user{Name: "Testy Tester", Email: "testy@example.com"}
+
+		logTo(&buf).Info("hello", "user", u)
+
+		assert.JSONEq(t, `{"level": "INFO", "msg": "hello", "user": {"Name": "Testy Tester", "Email": ""}}`, buf.String())
+		assert.Equal(t, "testy@example.com", u.Email)
+	})
+
+	t.Run("with a pointer to a struct containing a pointer, logs a scrubbed copy", func(t *testing.T) {
+		type user struct {
+			Email    string `scrub:"true"`
+			Password string
+		}
+		type account struct {
+			ID    int
+			Owner *user
+		}
+		var buf bytes.Buffer
+		u := user{Email: "testy@example.com", Password: "hunter2"}
+
+		logTo(&buf, "Password").Info("hello", "account", &account{ID: 1, Owner: &u})
+
+		assert.JSONEq(t, `{"level": "INFO", "msg": "hello", "account": {"ID": 1, "Owner": {"Email": "", "Password": ""}}}`, buf.String())
+		assert.Equal(t, user{Email: "testy@example.com", Password: "hunter2"}, u)
+	})
+
+	t.Run("with a cycle, logs a scrubbed copy", func(t *testing.T) {
+		type node struct {
+			Name   string `scrub:"true"`
+			Parent *node
+		}
+		var buf bytes.Buffer
+		n := &node{Name: "root"}
+		n.Parent = n
+
+		slog.New(NewHandler(slog.NewTextHandler(&buf, nil))).Info("hello", "node", n)
+
+		assert.Contains(t, buf.String(), "node=\"&{Name: Parent:0x")
+		assert.NotContains(t, buf.String(), "root")
+	})
+
+	t.Run("with a slice of structs, logs scrubbed copies", func(t *testing.T) {
+		type user struct {
+			Name  string
+			Email string `scrub:"true"`
+		}
+		var buf bytes.Buffer
+		users := []user{{Name: "a", Email: "a@example.com"}, {Name: "b", Email: "b@example.com"}}
+
+		logTo(&buf).Info("hello", "users", users)
+
+		assert.JSONEq(t, `{"level": "INFO", "msg": "hello", "users": [{"Name": "a", "Email": ""}, {"Name": "b", "Email": ""}]}`, buf.String())
+		assert.Equal(t, "a@example.com", users[0].Email)
+	})
+
+	t.Run("with a group, scrubs the attributes inside it", func(t *testing.T) {
+		type user struct {
+			Email string `scrub:"true"`
+		}
+		var buf bytes.Buffer
+
+		logTo(&buf).Info("hello", slog.Group("request", "user", user{Email: "testy@example.com"}, "id", 7))
+
+		assert.JSONEq(t, `{"level": "INFO", "msg": "hello", "request": {"user": {"Email": ""}, "id": 7}}`, buf.String())
+	})
+
+	t.Run("with a LogValuer that resolves to a struct, logs a scrubbed copy", func(t *testing.T) {
+		var buf bytes.Buffer
+
+		logTo(&buf).Info("hello", "user", loggedEmail{email: "testy@example.com"})
+
+		assert.JSONEq(t, `{"level": "INFO", "msg": "hello", "user": {"Email": ""}}`, buf.String())
+	})
+
+	t.Run("with attributes added using With, scrubs them", func(t *testing.T) {
+		type user struct {
+			Email string `scrub:"true"`
+		}
+		var buf bytes.Buffer
+
+		logTo(&buf).With("user", user{Email: "testy@example.com"}).WithGroup("g").Info("hello", "count", 1)
+
+		assert.JSONEq(t, `{"level": "INFO", "msg": "hello", "user": {"Email": ""}, "g": {"count": 1}}`, buf.String())
+	})
+
+	t.Run("with values that aren't structs, logs them unchanged", func(t *testing.T) {
+		var buf bytes.Buffer
+
+		logTo(&buf).Info("hello", "n", 1, "s", "text", "m", map[string]int{"a": 1}, "nil", nil)
+
+		assert.JSONEq(t, `{"level": "INFO", "msg": "hello", "n": 1, "s": "text", "m": {"a": 1}, "nil": null}`, buf.String())
+	})
+}
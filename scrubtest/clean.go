@@ -0,0 +1,161 @@
+package scrubtest
+
+import (
+	"fmt"
+	"reflect"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// AssertClean fails t if v still holds anything that should have been scrubbed: a field annotated with a
+// `scrub:"true"` struct tag, or selected by WithNames or WithTypes, that isn't zero, or a string that matches
+// a pattern added with WithPattern, WithUUIDs or WithTimestamps, or in which a detector added with
+// WithDetectors finds something. Byte slices are checked as strings. Unlike the scrub walker, it looks
+// everywhere, including maps, interfaces, arrays and unexported fields, so that it catches values that a
+// scrub missed, such as those in new kinds of nesting.
+//
+// The failure lists the paths of the offending values, written as in scrub.Diff with map keys elided, but
+// never the values themselves.
+func AssertClean(t testing.TB, v any, opts ...Option) {
+	t.Helper()
+	var o options
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	in := inspector{options: &o, seen: map[pointer]bool{}}
+	in.inspect("", reflect.ValueOf(v))
+	if len(in.problems) > 0 {
+		t.Errorf("scrubtest: value isn't clean:\n  %s", strings.Join(in.problems, "\n  "))
+	}
+}
+
+type pointer struct {
+	addr uintptr
+	typ  reflect.Type
+}
+
+type inspector struct {
+	*options
+	problems []string
+	// seen holds the pointers already inspected, so that cycles end.
+	seen map[pointer]bool
+}
+
+func (in *inspector) inspect(path string, v reflect.Value) {
+	if !v.IsValid() {
+		return
+	}
+	if slices.Contains(in.types, v.Type()) {
+		if !v.IsZero() {
+			in.report(path, "value of type %s isn't zero", v.Type())
+		}
+		return
+	}
+
+	switch v.Kind() {
+	case reflect.String:
+		in.match(path, v.String())
+	case reflect.Ptr:
+		if v.IsNil() {
+			return
+		}
+		key := pointer{addr: v.Pointer(), typ: v.Type()}
+		if in.seen[key] {
+			return
+		}
+		in.seen[key] = true
+		in.inspect(path, v.Elem())
+	case reflect.Interface:
+		in.inspect(path, v.Elem())
+	case reflect.Struct:
+		for i := 0; i < v.NumField(); i++ {
+			field := v.Type().Field(i)
+			fieldPath := field.Name
+			if path != "" {
+				fieldPath = path + "." + field.Name
+			}
+			if field.Tag.Get("scrub") == "true" || slices.Contains(in.names, field.Name) {
+				if !v.Field(i).IsZero() {
+					in.report(fieldPath, "scrubbed field isn't zero")
+				}
+				continue
+			}
+			in.inspect(fieldPath, v.Field(i))
+		}
+	case reflect.Slice, reflect.Array:
+		if v.Kind() == reflect.Slice && v.Type().Elem().Kind() == reflect.Uint8 {
+			in.match(path, string(v.Bytes()))
+			return
+		}
+		for i := 0; i < v.Len(); i++ {
+			in.inspect(path+"["+strconv.Itoa(i)+"]", v.Index(i))
+		}
+	case reflect.Map:
+		in.inspectMap(path, v)
+	}
+}
+
+// inspectMap inspects the keys and values of the map v in order of their formatted keys. Since keys can be
+// as sensitive as values, paths show each key's type and position in that order instead of the key itself,
+// as scrub.Diff does, as in Extra[<redacted string #1>].
+func (in *inspector) inspectMap(path string, v reflect.Value) {
+	type entry struct {
+		key   string
+		value reflect.Value
+		label string
+	}
+	entries := make([]entry, 0, v.Len())
+	iter := v.MapRange()
+	for iter.Next() {
+		e := entry{key: fmt.Sprint(iter.Key()), value: iter.Value()}
+		if iter.Key().Kind() == reflect.String {
+			e.key = strconv.Quote(iter.Key().String())
+			e.label, _ = in.matching(iter.Key().String())
+		}
+		entries = append(entries, e)
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].key < entries[j].key
+	})
+	for i, e := range entries {
+		entryPath := path + "[<redacted " + v.Type().Key().String() + " #" + strconv.Itoa(i) + ">]"
+		if e.label != "" {
+			in.report(entryPath, "map key matches %s", e.label)
+		}
+		in.inspect(entryPath, e.value)
+	}
+}
+
+// match reports s if it matches any of the patterns or detectors.
+func (in *inspector) match(path, s string) {
+	if label, ok := in.matching(s); ok {
+		in.report(path, "string matches %s", label)
+	}
+}
+
+// matching returns the placeholder of the first pattern that s matches, or else the label of the first
+// detector that finds something in s.
+func (in *inspector) matching(s string) (string, bool) {
+	for _, p := range in.patterns {
+		if p.re.MatchString(s) {
+			return p.placeholder, true
+		}
+	}
+	for _, d := range in.detectors {
+		if len(d.Find(s)) > 0 {
+			return detectorLabel(d), true
+		}
+	}
+	return "", false
+}
+
+func (in *inspector) report(path, format string, args ...any) {
+	if path == "" {
+		path = "(root)"
+	}
+	in.problems = append(in.problems, path+": "+fmt.Sprintf(format, args...))
+}
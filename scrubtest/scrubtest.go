@@ -0,0 +1,290 @@
+// Package scrubtest compares values against golden files, scrubbing the fields and values that change from
+// run to run, such as timestamps, UUIDs and request IDs, so that the comparison is stable.
+//
+// Golden files are kept in the testdata directory of the package under test. Run the tests with the -update
+// flag to write them, as in "go test ./api -update", and review the changes before committing them. The flag
+// is registered by this package unless the test binary already has one; packages that need it too should
+// read it with flag.Lookup rather than define their own. SCRUBTEST_UPDATE=1 in the environment works too.
+package scrubtest
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/acj/scrub"
+)
+
+// UpdateEnv is the environment variable that, when set to a true value such as "1", makes AssertGolden
+// write golden files instead of comparing with them.
+const UpdateEnv = "SCRUBTEST_UPDATE"
+
+func init() {
+	if flag.Lookup("update") == nil {
+		flag.Bool("update", false, "rewrite golden files with the actual values")
+	}
+}
+
+// updating reports whether golden files should be written: the -update flag is set, or UpdateEnv is true.
+func updating() bool {
+	if update, err := strconv.ParseBool(os.Getenv(UpdateEnv)); err == nil && update {
+		return true
+	}
+	f := flag.Lookup("update")
+	return f != nil && f.Value.String() == "true"
+}
+
+var (
+	// UUID matches UUIDs in any of their usual cases, such as "0b2e6c1a-5f3d-4c8e-9a7b-1d2e3f4a5b6c".
+	UUID = regexp.MustCompile(`[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}`)
+	// RFC3339 matches timestamps in the format used by encoding/json for time.Time, such as
+	// "2006-01-02T15:04:05.999999999Z07:00".
+	RFC3339 = regexp.MustCompile(`\d{4}-\d{2}-\d{2}T\d{2}:\d{2}:\d{2}(\.\d+)?(Z|[+-]\d{2}:\d{2})`)
+)
+
+// Option configures AssertGolden.
+type Option func(*options)
+
+type options struct {
+	dir       string
+	names     []string
+	types     []reflect.Type
+	patterns  []pattern
+	detectors []scrub.Detector
+}
+
+type pattern struct {
+	re          *regexp.Regexp
+	placeholder string
+}
+
+// WithNames also scrubs fields with any of the given names, as scrub.NamedFields does.
+func WithNames(names ...string) Option {
+	return func(o *options) {
+		o.names = append(o.names, names...)
+	}
+}
+
+// WithTypes scrubs every value with the same type as any of the given values, such as time.Time{}, wherever
+// it's found.
+func WithTypes(values ...any) Option {
+	return func(o *options) {
+		for _, v := range values {
+			o.types = append(o.types, reflect.TypeOf(v))
+		}
+	}
+}
+
+// WithPattern replaces every match of re in the string values of the serialized value with placeholder,
+// such as "<uuid>". Since it applies after serialization, it also finds values written by MarshalJSON
+// methods, such as those of time.Time.
+func WithPattern(re *regexp.Regexp, placeholder string) Option {
+	return func(o *options) {
+		o.patterns = append(o.patterns, pattern{re: re, placeholder: placeholder})
+	}
+}
+
+// WithUUIDs replaces UUIDs with "<uuid>".
+func WithUUIDs() Option {
+	return WithPattern(UUID, "<uuid>")
+}
+
+// WithTimestamps replaces RFC 3339 timestamps with "<timestamp>".
+func WithTimestamps() Option {
+	return WithPattern(RFC3339, "<timestamp>")
+}
+
+// WithDetectors replaces what any of the given detectors, such as those from scrub.SecretDetectors, find in
+// the string values of the serialized value with the detector's name in angle brackets, such as "<jwt>".
+// Like WithPattern, it applies after serialization.
+func WithDetectors(detectors ...scrub.Detector) Option {
+	return func(o *options) {
+		o.detectors = append(o.detectors, detectors...)
+	}
+}
+
+// WithDir keeps the golden files in dir instead of testdata.
+func WithDir(dir string) Option {
+	return func(o *options) {
+		o.dir = dir
+	}
+}
+
+// AssertGolden scrubs a copy of v and compares its serialized form with the golden file named name plus
+// ".golden", failing t with a diff if they differ. With the -update flag, or SCRUBTEST_UPDATE=1, it writes
+// the file instead.
+//
+// Fields annotated with a `scrub:"true"` struct tag are always zeroed, and options select more fields and
+// values to scrub. The copy is serialized as indented JSON, with map keys sorted, so the golden files are
+// deterministic and easy to review. v itself is left unchanged.
+func AssertGolden(t testing.TB, name string, v any, opts ...Option) {
+	t.Helper()
+	o := options{dir: "testdata"}
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	got, err := o.serialize(v)
+	if err != nil {
+		t.Fatalf("scrubtest: serializing %s: %v", name, err)
+	}
+
+	path := filepath.Join(o.dir, name+".golden")
+	if updating() {
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatalf("scrubtest: %v", err)
+		}
+		if err := os.WriteFile(path, got, 0o644); err != nil {
+			t.Fatalf("scrubtest: %v", err)
+		}
+		t.Logf("scrubtest: wrote %s", path)
+		return
+	}
+
+	want, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		t.Fatalf("scrubtest: golden file %s doesn't exist; run the test with -update to create it", path)
+	}
+	if err != nil {
+		t.Fatalf("scrubtest: %v", err)
+	}
+	assert.Equal(t, string(want), string(got), "scrubtest: %s differs from the golden file; run the test with -update to rewrite it", name)
+}
+
+// serialize returns the scrubbed form of v written to golden files.
+func (o *options) serialize(v any) ([]byte, error) {
+	copied := scrub.Copy(v)
+	o.clean(reflect.ValueOf(&copied).Elem(), map[uintptr]bool{})
+
+	data, err := json.Marshal(copied)
+	if err != nil {
+		return nil, err
+	}
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	var doc any
+	if err := dec.Decode(&doc); err != nil {
+		return nil, err
+	}
+
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(o.replace(doc)); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// clean zeroes the parts of v, which must be settable, that are selected by tag, name or type. seen holds
+// the pointers already cleaned, so that cycles end.
+func (o *options) clean(v reflect.Value, seen map[uintptr]bool) {
+	if slices.Contains(o.types, v.Type()) {
+		v.SetZero()
+		return
+	}
+
+	switch v.Kind() {
+	case reflect.Ptr:
+		if v.IsNil() || seen[v.Pointer()] {
+			return
+		}
+		seen[v.Pointer()] = true
+		o.clean(v.Elem(), seen)
+	case reflect.Interface:
+		if v.IsNil() {
+			return
+		}
+		// The value in an interface isn't settable, so clean a copy of it.
+		elem := reflect.New(v.Elem().Type()).Elem()
+		elem.Set(v.Elem())
+		o.clean(elem, seen)
+		v.Set(elem)
+	case reflect.Struct:
+		for i := 0; i < v.NumField(); i++ {
+			field := v.Type().Field(i)
+			if !field.IsExported() {
+				continue
+			}
+			if field.Tag.Get("scrub") == "true" || slices.Contains(o.names, field.Name) {
+				v.Field(i).SetZero()
+				continue
+			}
+			o.clean(v.Field(i), seen)
+		}
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < v.Len(); i++ {
+			o.clean(v.Index(i), seen)
+		}
+	case reflect.Map:
+		iter := v.MapRange()
+		for iter.Next() {
+			elem := reflect.New(v.Type().Elem()).Elem()
+			elem.Set(iter.Value())
+			o.clean(elem, seen)
+			v.SetMapIndex(iter.Key(), elem)
+		}
+	}
+}
+
+// replace applies the patterns to the string values in doc, which was decoded from JSON.
+func (o *options) replace(doc any) any {
+	switch doc := doc.(type) {
+	case string:
+		for _, p := range o.patterns {
+			doc = p.re.ReplaceAllLiteralString(doc, p.placeholder)
+		}
+		for _, d := range o.detectors {
+			doc = replaceFound(doc, d)
+		}
+		return doc
+	case []any:
+		for i, elem := range doc {
+			doc[i] = o.replace(elem)
+		}
+	case map[string]any:
+		for k, elem := range doc {
+			doc[k] = o.replace(elem)
+		}
+	}
+	return doc
+}
+
+// replaceFound replaces what d finds in s with d's name in angle brackets.
+func replaceFound(s string, d scrub.Detector) string {
+	found := d.Find(s)
+	if len(found) == 0 {
+		return s
+	}
+	var b strings.Builder
+	end := 0
+	for _, loc := range found {
+		if loc[0] < end {
+			// Skip matches that overlap the previous one.
+			continue
+		}
+		b.WriteString(s[end:loc[0]])
+		b.WriteString(detectorLabel(d))
+		end = loc[1]
+	}
+	b.WriteString(s[end:])
+	return b.String()
+}
+
+// detectorLabel returns the name of d in angle brackets, as written in golden files and failures.
+func detectorLabel(d scrub.Detector) string {
+	return "<" + fmt.Sprint(d) + ">"
+}
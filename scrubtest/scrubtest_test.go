@@ -0,0 +1,187 @@
+package scrubtest
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/acj/scrub"
+)
+
+// fakeT records the failures reported by AssertGolden. Fatalf ends the goroutine, like testing.T's does.
+type fakeT struct {
+	testing.TB
+	errors []string
+}
+
+func (t *fakeT) Helper() {}
+
+func (t *fakeT) Name() string { return "fakeT" }
+
+func (t *fakeT) Logf(string, ...any) {}
+
+func (t *fakeT) Errorf(format string, args ...any) {
+	t.errors = append(t.errors, fmt.Sprintf(format, args...))
+}
+
+func (t *fakeT) Fatalf(format string, args ...any) {
+	t.Errorf(format, args...)
+	runtime.Goexit()
+}
+
+// run calls f with a fakeT in a new goroutine, so that Fatalf can end it, and returns the fakeT.
+func run(f func(t testing.TB)) *fakeT {
+	ft := &fakeT{}
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		f(ft)
+	}()
+	<-done
+	return ft
+}
+
+// setUpdate sets the -update flag for the rest of the test.
+func setUpdate(t *testing.T) {
+	require.NoError(t, flag.Set("update", "true"))
+	t.Cleanup(func() {
+		_ = flag.Set("update", "false")
+	})
+}
+
+func TestAssertGolden(t *testing.T) {
+	type user struct {
+		ID      string
+		Name    string
+		Session string
+	}
+	type event struct {
+		Name string
+		At   time.Time
+		Took time.Duration
+	}
+	type response struct {
+		RequestID string `scrub:"true"`
+		User      user
+		Events    []event
+		Meta      map[string]any
+	}
+	newResponse := func(id, session string, at time.Time) *response {
+		return &response{
+			RequestID: "req_" + session,
+			User:      user{ID: id, Name: "Testy <Tester>", Session: session},
+			Events: []event{
+				{Name: "login", At: at, Took: time.Duration(len(session)) * time.Millisecond},
+				{Name: "view", At: at.Add(time.Minute)},
+			},
+			Meta: map[string]any{
+				"trace":   "/traces/" + id,
+				"version": 3,
+				"nested":  map[string]any{"Took": 1},
+			},
+		}
+	}
+	// newResponses returns two responses that differ only in noisy fields and values.
+	newResponses := func() (*response, *response) {
+		first := newResponse("0b2e6c1a-5f3d-4c8e-9a7b-1d2e3f4a5b6c", "s1", time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC))
+		second := newResponse("9F8E7D6C-5B4A-4938-8271-605F4E3D2C1B", "session2", time.Now())
+		return first, second
+	}
+	goldenOpts := []Option{WithNames("Session"), WithTypes(time.Duration(0)), WithUUIDs(), WithTimestamps()}
+
+	t.Run("with noisy fields and values, matches the golden file for every run", func(t *testing.T) {
+		first, second := newResponses()
+
+		AssertGolden(t, "response", first, goldenOpts...)
+		AssertGolden(t, "response", second, goldenOpts...)
+	})
+
+	t.Run("with a value, leaves it unchanged", func(t *testing.T) {
+		first, _ := newResponses()
+		expected, _ := newResponses()
+
+		AssertGolden(t, "response", first, goldenOpts...)
+
+		assert.Equal(t, expected, first)
+	})
+
+	t.Run("with a difference, fails with a diff", func(t *testing.T) {
+		first, _ := newResponses()
+		first.User.Name = "Imposter"
+
+		ft := run(func(ft testing.TB) {
+			AssertGolden(ft, "response", first, goldenOpts...)
+		})
+
+		require.Len(t, ft.errors, 1)
+		assert.Contains(t, ft.errors[0], "Imposter")
+		assert.Contains(t, ft.errors[0], "run the test with -update to rewrite it")
+	})
+
+	t.Run("without a golden file, fails and says how to create it", func(t *testing.T) {
+		ft := run(func(ft testing.TB) {
+			AssertGolden(ft, "missing", response{}, WithDir(t.TempDir()))
+		})
+
+		require.Len(t, ft.errors, 1)
+		assert.Contains(t, ft.errors[0], "run the test with -update to create it")
+	})
+
+	t.Run("with -update set, writes the golden file", func(t *testing.T) {
+		dir := t.TempDir()
+		first, second := newResponses()
+		setUpdate(t)
+
+		AssertGolden(t, "nested/response", second, append(goldenOpts, WithDir(dir))...)
+
+		written, err := os.ReadFile(filepath.Join(dir, "nested", "response.golden"))
+		require.NoError(t, err)
+		expected, err := os.ReadFile(filepath.Join("testdata", "response.golden"))
+		require.NoError(t, err)
+		assert.Equal(t, string(expected), string(written))
+		assert.NotEqual(t, first, second)
+	})
+
+	t.Run("with SCRUBTEST_UPDATE set, writes the golden file", func(t *testing.T) {
+		t.Setenv(UpdateEnv, "1")
+		dir := t.TempDir()
+
+		AssertGolden(t, "flag", map[string]int{"n": 1}, WithDir(dir))
+
+		written, err := os.ReadFile(filepath.Join(dir, "flag.golden"))
+		require.NoError(t, err)
+		assert.Equal(t, "{\n  \"n\": 1\n}\n", string(written))
+	})
+
+	t.Run("with detectors, replaces what they find with their names", func(t *testing.T) {
+		dir := t.TempDir()
+		setUpdate(t)
+
+		AssertGolden(t, "detectors", map[string]string{"log": "sent Bearer abc123xyz to testy@example.com"},
+			WithDir(dir), WithDetectors(scrub.BearerToken, scrub.Email))
+
+		written, err := os.ReadFile(filepath.Join(dir, "detectors.golden"))
+		require.NoError(t, err)
+		assert.Equal(t, "{\n  \"log\": \"sent Bearer <bearer_token> to <email>\"\n}\n", string(written))
+	})
+
+	t.Run("with a custom pattern, replaces its matches", func(t *testing.T) {
+		dir := t.TempDir()
+		setUpdate(t)
+
+		AssertGolden(t, "pattern", map[string]string{"card": "card 4111 1111 1111 1111 on file"},
+			WithDir(dir), WithPattern(regexp.MustCompile(`(\d{4} ?){4}`), "<card>"))
+
+		written, err := os.ReadFile(filepath.Join(dir, "pattern.golden"))
+		require.NoError(t, err)
+		assert.Equal(t, "{\n  \"card\": \"card <card>on file\"\n}\n", string(written))
+	})
+}
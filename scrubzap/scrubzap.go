@@ -0,0 +1,82 @@
+// Package scrubzap scrubs struct values in go.uber.org/zap fields before they're logged.
+//
+// Struct values, pointers to structs and slices of either are replaced with scrubbed copies, leaving the
+// original values unchanged. Fields annotated with a `scrub:"true"` struct tag are always scrubbed; fields
+// with any of the names passed to NewCore or Object are scrubbed as well.
+package scrubzap
+
+import (
+	"errors"
+
+	"go.uber.org/zap/zapcore"
+)
+
+// NewCore returns a zapcore.Core that scrubs struct values in fields, including those added with With,
+// before passing them on to c. This enforces scrubbing for every field logged through the core, such as
+// those created with zap.Any, zap.Reflect, zap.Object, zap.Array, zap.Inline and zap.Stringer.
+//
+// The returned core decides whether to log an entry using c.Enabled, so cores that do more than that in
+// Check, such as samplers, should wrap the returned core rather than be wrapped by it.
+func NewCore(c zapcore.Core, names ...string) zapcore.Core {
+	return &core{Core: c, names: names}
+}
+
+type core struct {
+	zapcore.Core
+	names []string
+}
+
+func (c *core) With(fields []zapcore.Field) zapcore.Core {
+	return &core{Core: c.Core.With(scrubFields(fields, c.names)), names: c.names}
+}
+
+func (c *core) Check(entry zapcore.Entry, checked *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if c.Enabled(entry.Level) {
+		return checked.AddCore(entry, c)
+	}
+	return checked
+}
+
+func (c *core) Write(entry zapcore.Entry, fields []zapcore.Field) error {
+	return c.Core.Write(entry, scrubFields(fields, c.names))
+}
+
+func scrubFields(fields []zapcore.Field, names []string) []zapcore.Field {
+	scrubbed := make([]zapcore.Field, len(fields))
+	for i, f := range fields {
+		switch f.Type {
+		case zapcore.ReflectType, zapcore.ObjectMarshalerType, zapcore.InlineMarshalerType,
+			zapcore.ArrayMarshalerType, zapcore.StringerType:
+			f.Interface = scrubbedCopy(f.Interface, names)
+		}
+		scrubbed[i] = f
+	}
+	return scrubbed
+}
+
+// Object returns a zapcore.ObjectMarshaler that logs a scrubbed copy of v, for use with zap.Object. If the
+// copy implements zapcore.ObjectMarshaler itself, it's used to encode the copy; otherwise, each exported
+// field is encoded using reflection.
+func Object(v any, names ...string) zapcore.ObjectMarshaler {
+	return object{v: v, names: names}
+}
+
+type object struct {
+	v     any
+	names []string
+}
+
+func (o object) MarshalLogObject(enc zapcore.ObjectEncoder) error {
+	v := scrubbedCopy(o.v, o.names)
+	if m, ok := v.(zapcore.ObjectMarshaler); ok {
+		return m.MarshalLogObject(enc)
+	}
+
+	var errs []error
+	eachField(v, func(key string, value any) {
+		if err := enc.AddReflected(key, value); err != nil {
+			errs = append(errs, err)
+		}
+	})
+	return errors.Join(errs...)
+}
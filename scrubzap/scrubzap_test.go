@@ -0,0 +1,142 @@
+package scrubzap
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// marshaledUser implements zapcore.ObjectMarshaler, which needs a package-level type.
+type marshaledUser struct {
+	Name  string
+	Email string `scrub:"true"`
+}
+
+func (u marshaledUser) MarshalLogObject(enc zapcore.ObjectEncoder) error {
+	enc.AddString("name", u.Name)
+	enc.AddString("email", u.Email)
+	return nil
+}
+
+func TestNewCore(t *testing.T) {
+	logTo := func(buf *bytes.Buffer, level zapcore.Level, names ...string) *zap.Logger {
+		config := zap.NewProductionEncoderConfig()
+		config.TimeKey = ""
+		encoder := zapcore.NewJSONEncoder(config)
+		return zap.New(NewCore(zapcore.NewCore(encoder, zapcore.AddSync(buf), level), names...))
+	}
+
+	t.Run("with a struct value, logs a scrubbed copy", func(t *testing.T) {
+		type user struct {
+			Name     string `json:"name"`
+			Email    string `json:"email" scrub:"true"`
+			Internal string `json:"-"`
+		}
+		var buf bytes.Buffer
+		u := user{Name: "Testy Tester", Email: "testy@example.com", Internal: "x"}
+
+		logTo(&buf, zapcore.DebugLevel).Info("hello", zap.Any("user", u))
+
+		assert.JSONEq(t, `{"level": "info", "msg": "hello", "user": {"name": "Testy Tester", "email": ""}}`, buf.String())
+		assert.Equal(t, "testy@example.com", u.Email)
+	})
+
+	t.Run("with names, also scrubs the named fields", func(t *testing.T) {
+		type user struct {
+			Name     string
+			Password string
+		}
+		var buf bytes.Buffer
+		u := user{Name: "Testy Tester", Password: "hunter2"}
+
+		logTo(&buf, zapcore.DebugLevel, "Password").Info("hello", zap.Reflect("user", &u))
+
+		assert.JSONEq(t, `{"level": "info", "msg": "hello", "user": {"Name": "Testy Tester", "Password": ""}}`, buf.String())
+		assert.Equal(t, "hunter2", u.Password)
+	})
+
+	t.Run("with an object marshaler, scrubs the value before marshaling it", func(t *testing.T) {
+		var buf bytes.Buffer
+
+		logTo(&buf, zapcore.DebugLevel).Info("hello", zap.Object("user", marshaledUser{Name: "Testy Tester", Email: "testy@example.com"}))
+
+		assert.JSONEq(t, `{"level": "info", "msg": "hello", "user": {"name": "Testy Tester", "email": ""}}`, buf.String())
+	})
+
+	t.Run("with fields added using With, scrubs them", func(t *testing.T) {
+		type user struct {
+			Email string `scrub:"true"`
+		}
+		var buf bytes.Buffer
+
+		logTo(&buf, zapcore.DebugLevel).With(zap.Any("user", user{Email: "testy@example.com"})).Info("hello")
+
+		assert.JSONEq(t, `{"level": "info", "msg": "hello", "user": {"Email": ""}}`, buf.String())
+	})
+
+	t.Run("below the core's level, logs nothing", func(t *testing.T) {
+		type user struct {
+			Email string `scrub:"true"`
+		}
+		var buf bytes.Buffer
+
+		logTo(&buf, zapcore.InfoLevel).Debug("hello", zap.Any("user", user{Email: "testy@example.com"}))
+
+		assert.Empty(t, buf.String())
+	})
+
+	t.Run("with values that aren't structs, logs them unchanged", func(t *testing.T) {
+		var buf bytes.Buffer
+
+		logTo(&buf, zapcore.DebugLevel).Info("hello", zap.Int("n", 1), zap.String("s", "text"), zap.Any("m", map[string]int{"a": 1}))
+
+		assert.JSONEq(t, `{"level": "info", "msg": "hello", "n": 1, "s": "text", "m": {"a": 1}}`, buf.String())
+	})
+}
+
+func TestObject(t *testing.T) {
+	logTo := func(buf *bytes.Buffer) *zap.Logger {
+		config := zap.NewProductionEncoderConfig()
+		config.TimeKey = ""
+		encoder := zapcore.NewJSONEncoder(config)
+		return zap.New(zapcore.NewCore(encoder, zapcore.AddSync(buf), zapcore.DebugLevel))
+	}
+
+	t.Run("with a struct value, logs the fields of a scrubbed copy", func(t *testing.T) {
+		type user struct {
+			Name     string `json:"name"`
+			Email    string `json:"email" scrub:"true"`
+			Password string `json:"password"`
+		}
+		var buf bytes.Buffer
+		u := user{Name: "Testy Tester", Email: "testy@example.com", Password: "hunter2"}
+
+		logTo(&buf).Info("hello", zap.Object("user", Object(u, "Password")))
+
+		assert.JSONEq(t, `{"level": "info", "msg": "hello", "user": {"name": "Testy Tester", "email": "", "password": ""}}`, buf.String())
+		assert.Equal(t, "hunter2", u.Password)
+	})
+
+	t.Run("with a value that implements ObjectMarshaler, uses it to log a scrubbed copy", func(t *testing.T) {
+		var buf bytes.Buffer
+
+		logTo(&buf).Info("hello", zap.Object("user", Object(&marshaledUser{Name: "Testy Tester", Email: "testy@example.com"})))
+
+		assert.JSONEq(t, `{"level": "info", "msg": "hello", "user": {"name": "Testy Tester", "email": ""}}`, buf.String())
+	})
+
+	t.Run("with a nil pointer, logs an empty object", func(t *testing.T) {
+		type user struct {
+			Name string
+		}
+		var buf bytes.Buffer
+		var u *user
+
+		logTo(&buf).Info("hello", zap.Object("user", Object(u)))
+
+		assert.JSONEq(t, `{"level": "info", "msg": "hello", "user": {}}`, buf.String())
+	})
+}
@@ -0,0 +1,89 @@
+package scrubzap
+
+import (
+	"context"
+	"reflect"
+	"strings"
+
+	"github.com/acj/scrub"
+)
+
+// scrubbedCopy returns a scrubbed copy of v if it's a struct, a pointer to a struct, or a slice of either.
+// Fields annotated with a `scrub:"true"` struct tag are scrubbed, as are fields with any of the given
+// names. Values with cycles are scrubbed too, since loggers must not hang on them. Otherwise, it returns v.
+//
+// scrubbedCopy and eachField are copies of the helpers that scrubslog uses, which are internal to the root
+// module and so can't be imported from this one.
+func scrubbedCopy(v any, names []string) any {
+	rv := reflect.ValueOf(v)
+	switch {
+	case !rv.IsValid():
+		return v
+	case rv.Kind() == reflect.Struct:
+		p := reflect.New(rv.Type())
+		p.Elem().Set(reflect.ValueOf(scrub.Copy(v)))
+		scrubStruct(p.Interface(), names)
+		return p.Elem().Interface()
+	case rv.Kind() == reflect.Ptr && rv.Type().Elem().Kind() == reflect.Struct:
+		copied := scrub.Copy(v)
+		scrubStruct(copied, names)
+		return copied
+	case rv.Kind() == reflect.Slice && containsStructs(rv.Type().Elem()):
+		copied := reflect.ValueOf(scrub.Copy(v))
+		for i := 0; i < copied.Len(); i++ {
+			elem := copied.Index(i)
+			if elem.Kind() == reflect.Struct {
+				elem = elem.Addr()
+			}
+			scrubStruct(elem.Interface(), names)
+		}
+		return copied.Interface()
+	default:
+		return v
+	}
+}
+
+// eachField calls fn with the key and value of each exported field of the struct v, or of the struct v points
+// to, in declaration order. Keys come from json struct tags where present, and fields tagged `json:"-"` are
+// skipped. eachField is a no-op for other types and nil pointers.
+func eachField(v any, fn func(key string, value any)) {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() == reflect.Ptr {
+		rv = rv.Elem()
+	}
+	if rv.Kind() != reflect.Struct {
+		return
+	}
+	for i := 0; i < rv.NumField(); i++ {
+		field := rv.Type().Field(i)
+		if !field.IsExported() {
+			continue
+		}
+		key := field.Name
+		if tag, ok := field.Tag.Lookup("json"); ok {
+			name, _, _ := strings.Cut(tag, ",")
+			if name == "-" {
+				continue
+			}
+			if name != "" {
+				key = name
+			}
+		}
+		fn(key, rv.Field(i).Interface())
+	}
+}
+
+func scrubStruct(v any, names []string) {
+	ctx := context.Background()
+	_ = scrub.TaggedFieldsContext(ctx, v, scrub.WithCycleDetection())
+	if len(names) > 0 {
+		_ = scrub.NamedFieldsContext(ctx, v, names, scrub.WithCycleDetection())
+	}
+}
+
+func containsStructs(t reflect.Type) bool {
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	return t.Kind() == reflect.Struct
+}
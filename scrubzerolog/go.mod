@@ -0,0 +1,22 @@
+module github.com/acj/scrub/scrubzerolog
+
+go 1.24.0
+
+require (
+	github.com/acj/scrub v0.1.0
+	github.com/rs/zerolog v1.34.0
+	github.com/stretchr/testify v1.9.0
+)
+
+require (
+	github.com/davecgh/go-spew v1.1.1 // indirect
+	github.com/mattn/go-colorable v0.1.13 // indirect
+	github.com/mattn/go-isatty v0.0.19 // indirect
+	github.com/pmezard/go-difflib v1.0.0 // indirect
+	golang.org/x/sys v0.28.0 // indirect
+	gopkg.in/yaml.v3 v3.0.1 // indirect
+)
+
+// The replace is for developing both modules in this repository. Modules that depend on this one ignore it
+// and use the required version of scrub.
+replace github.com/acj/scrub => ../
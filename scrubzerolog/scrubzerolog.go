@@ -0,0 +1,46 @@
+// Package scrubzerolog scrubs struct values logged with github.com/rs/zerolog.
+//
+// Struct values, pointers to structs and slices of either are replaced with scrubbed copies, leaving the
+// original values unchanged. Fields annotated with a `scrub:"true"` struct tag are always scrubbed; fields
+// with any of the names passed to MarshalFunc or Object are scrubbed as well.
+//
+// zerolog hooks can't see the fields that have already been added to an event, so scrubbing is enforced for
+// a whole logger by replacing zerolog.InterfaceMarshalFunc with the function returned by MarshalFunc:
+//
+//	zerolog.InterfaceMarshalFunc = scrubzerolog.MarshalFunc(json.Marshal, "Password")
+package scrubzerolog
+
+import "github.com/rs/zerolog"
+
+// MarshalFunc returns a function for use as zerolog.InterfaceMarshalFunc that scrubs values before
+// marshaling them with marshal. It applies to values logged with Event.Interface, Event.Any,
+// Context.Interface and the like.
+func MarshalFunc(marshal func(v any) ([]byte, error), names ...string) func(v any) ([]byte, error) {
+	return func(v any) ([]byte, error) {
+		return marshal(scrubbedCopy(v, names))
+	}
+}
+
+// Object returns a zerolog.LogObjectMarshaler that logs a scrubbed copy of v, for use with Event.Object. If
+// the copy implements zerolog.LogObjectMarshaler itself, it's used to encode the copy; otherwise, each
+// exported field is logged with Event.Interface.
+func Object(v any, names ...string) zerolog.LogObjectMarshaler {
+	return object{v: v, names: names}
+}
+
+type object struct {
+	v     any
+	names []string
+}
+
+func (o object) MarshalZerologObject(e *zerolog.Event) {
+	v := scrubbedCopy(o.v, o.names)
+	if m, ok := v.(zerolog.LogObjectMarshaler); ok {
+		m.MarshalZerologObject(e)
+		return
+	}
+
+	eachField(v, func(key string, value any) {
+		e.Interface(key, value)
+	})
+}
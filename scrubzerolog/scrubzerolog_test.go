@@ -0,0 +1,103 @@
+package scrubzerolog
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+)
+
+// marshaledUser implements zerolog.LogObjectMarshaler, which needs a package-level type.
+type marshaledUser struct {
+	Name  string
+	Email string `scrub:"true"`
+}
+
+func (u marshaledUser) MarshalZerologObject(e *zerolog.Event) {
+	e.Str("name", u.Name).Str("email", u.Email)
+}
+
+func TestMarshalFunc(t *testing.T) {
+	withMarshalFunc := func(t *testing.T, names ...string) {
+		original := zerolog.InterfaceMarshalFunc
+		zerolog.InterfaceMarshalFunc = MarshalFunc(json.Marshal, names...)
+		t.Cleanup(func() {
+			zerolog.InterfaceMarshalFunc = original
+		})
+	}
+
+	t.Run("with a struct value, logs a scrubbed copy", func(t *testing.T) {
+		type user struct {
+			Name  string `json:"name"`
+			Email string `json:"email" scrub:"true"`
+		}
+		withMarshalFunc(t)
+		var buf bytes.Buffer
+		u := user{Name: "Testy Tester", Email: "testy@example.com"}
+
+		logger := zerolog.New(&buf)
+		logger.Info().Interface("user", u).Msg("hello")
+
+		assert.JSONEq(t, `{"level": "info", "message": "hello", "user": {"name": "Testy Tester", "email": ""}}`, buf.String())
+		assert.Equal(t, "testy@example.com", u.Email)
+	})
+
+	t.Run("with names and a pointer in the logger's context, also scrubs the named fields", func(t *testing.T) {
+		type user struct {
+			Name     string `json:"name"`
+			Password string `json:"password"`
+		}
+		withMarshalFunc(t, "Password")
+		var buf bytes.Buffer
+		u := user{Name: "Testy Tester", Password: "hunter2"}
+
+		logger := zerolog.New(&buf).With().Interface("user", &u).Logger()
+		logger.Info().Msg("hello")
+
+		assert.JSONEq(t, `{"level": "info", "message": "hello", "user": {"name": "Testy Tester", "password": ""}}`, buf.String())
+		assert.Equal(t, "hunter2", u.Password)
+	})
+
+	t.Run("with a slice of structs, logs scrubbed copies", func(t *testing.T) {
+		type user struct {
+			Name  string `json:"name"`
+			Email string `json:"email" scrub:"true"`
+		}
+		withMarshalFunc(t)
+		var buf bytes.Buffer
+
+		logger := zerolog.New(&buf)
+		logger.Info().Any("users", []user{{Name: "Testy Tester", Email: "testy@example.com"}}).Msg("hello")
+
+		assert.JSONEq(t, `{"level": "info", "message": "hello", "users": [{"name": "Testy Tester", "email": ""}]}`, buf.String())
+	})
+}
+
+func TestObject(t *testing.T) {
+	t.Run("with a struct value, logs the fields of a scrubbed copy", func(t *testing.T) {
+		type user struct {
+			Name     string `json:"name"`
+			Email    string `json:"email" scrub:"true"`
+			Password string `json:"password"`
+		}
+		var buf bytes.Buffer
+		u := user{Name: "Testy Tester", Email: "testy@example.com", Password: "hunter2"}
+
+		logger := zerolog.New(&buf)
+		logger.Info().Object("user", Object(u, "Password")).Msg("hello")
+
+		assert.JSONEq(t, `{"level": "info", "message": "hello", "user": {"name": "Testy Tester", "email": "", "password": ""}}`, buf.String())
+		assert.Equal(t, "hunter2", u.Password)
+	})
+
+	t.Run("with a value that implements LogObjectMarshaler, uses it to log a scrubbed copy", func(t *testing.T) {
+		var buf bytes.Buffer
+
+		logger := zerolog.New(&buf)
+		logger.Info().Object("user", Object(marshaledUser{Name: "Testy Tester", Email: "testy@example.com"})).Msg("hello")
+
+		assert.JSONEq(t, `{"level": "info", "message": "hello", "user": {"name": "Testy Tester", "email": ""}}`, buf.String())
+	})
+}
@@ -0,0 +1,151 @@
+package scrub
+
+import (
+	"net/url"
+	"reflect"
+	"strings"
+)
+
+// urlParams are the query parameters that URL always removes, compared case-insensitively.
+var urlParams = []string{
+	"password", "passwd", "pwd", "pass", "sslpassword", "secret", "client_secret",
+	"token", "access_token", "refresh_token", "id_token", "auth", "api_key", "apikey",
+	"sig", "signature", "x-amz-signature", "x-amz-credential", "x-amz-security-token",
+}
+
+// URLParams returns the query parameters that URL always removes, such as "password" and "token".
+func URLParams() []string {
+	return append([]string(nil), urlParams...)
+}
+
+// URLRemoving returns an action like URL that also removes the given query parameters, such as
+// "url:session+sid".
+func URLRemoving(params ...string) Action {
+	if len(params) == 0 {
+		return URL
+	}
+	return URL + ":" + Action(strings.Join(params, "+"))
+}
+
+// urlParams returns the extra query parameters that a removes, if it's URL or was returned by URLRemoving.
+func (a Action) urlParams() ([]string, bool) {
+	if a == URL {
+		return nil, true
+	}
+	list, ok := strings.CutPrefix(string(a), string(URL)+":")
+	if !ok || list == "" {
+		return nil, false
+	}
+	// Cut, unlike Split, doesn't make a escape to the heap while scrubbing.
+	var params []string
+	for more := true; more; {
+		var param string
+		param, list, more = strings.Cut(list, "+")
+		params = append(params, param)
+	}
+	return params, true
+}
+
+// scrubURL returns s with the password and the given query parameters removed, or MaskPlaceholder if s isn't
+// a valid URL.
+func scrubURL(s string, params []string) string {
+	u, err := url.Parse(s)
+	if err != nil {
+		return MaskPlaceholder
+	}
+	scrubbed := scrubParsedURL(*u, params)
+	return scrubbed.String()
+}
+
+// scrubParsedURL returns u with the password and the query parameters that URL removes taken out. Parameters
+// in the fragment, as in OAuth's "#access_token=...", are removed as well. URLs that aren't hierarchical,
+// with a scheme and a host, are masked, since url.Parse takes the user in DSNs such as
+// "bob:secret@tcp(db:3306)/app" for a scheme and leaves the password in the opaque part.
+func scrubParsedURL(u url.URL, params []string) url.URL {
+	if u == (url.URL{}) {
+		return u
+	}
+	if u.Scheme == "" || u.Opaque != "" || u.Host == "" {
+		return url.URL{Opaque: MaskPlaceholder}
+	}
+	return RewriteURL(u, func(name, value string) (string, bool) {
+		return value, !isURLParam(name, params)
+	})
+}
+
+// RewriteURL returns u with the password removed, and with each parameter in its query, or in its fragment,
+// as in OAuth's "#access_token=...", passed to replace with its unescaped name and value. replace returns
+// the parameter's new value, and false to remove the parameter. Parameters keep their order, and those
+// whose values don't change keep their escaping. New values are escaped as in a query, except for
+// asterisks, which are allowed there and keep MaskPlaceholder readable.
+func RewriteURL(u url.URL, replace func(name, value string) (string, bool)) url.URL {
+	if u.User != nil {
+		if _, ok := u.User.Password(); ok {
+			u.User = url.User(u.User.Username())
+		}
+	}
+	if u.RawQuery != "" {
+		u.RawQuery = rewriteParams(u.RawQuery, replace)
+	}
+	if strings.Contains(u.Fragment, "=") {
+		u.Fragment = rewriteParams(u.Fragment, replace)
+		u.RawFragment = ""
+	}
+	return u
+}
+
+// rewriteParams passes the parameters in query to replace, as RewriteURL does.
+func rewriteParams(query string, replace func(name, value string) (string, bool)) string {
+	var kept []string
+	for _, pair := range strings.Split(query, "&") {
+		key, value, _ := strings.Cut(pair, "=")
+		name, err := url.QueryUnescape(key)
+		if err != nil {
+			name = key
+		}
+		if unescaped, err := url.QueryUnescape(value); err == nil {
+			value = unescaped
+		}
+		replaced, ok := replace(name, value)
+		switch {
+		case !ok:
+		case replaced == value:
+			kept = append(kept, pair)
+		default:
+			kept = append(kept, key+"="+strings.ReplaceAll(url.QueryEscape(replaced), "%2A", "*"))
+		}
+	}
+	return strings.Join(kept, "&")
+}
+
+func isURLParam(key string, params []string) bool {
+	for _, lists := range [][]string{urlParams, params} {
+		for _, p := range lists {
+			if strings.EqualFold(key, p) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+var urlType = reflect.TypeOf(url.URL{})
+
+// applyURL replaces field, if it's a url.URL or a non-nil *url.URL, as a does, and reports whether it did.
+// Pointers are replaced with new ones, since the old ones may be shared.
+func applyURL(field reflect.Value, a Action) bool {
+	params, ok := a.urlParams()
+	if !ok {
+		return false
+	}
+	switch {
+	case field.Type() == urlType:
+		field.Set(reflect.ValueOf(scrubParsedURL(field.Interface().(url.URL), params)))
+		return true
+	case field.Kind() == reflect.Ptr && field.Type().Elem() == urlType && !field.IsNil():
+		scrubbed := scrubParsedURL(*field.Interface().(*url.URL), params)
+		field.Set(reflect.ValueOf(&scrubbed))
+		return true
+	}
+	return false
+}